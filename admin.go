@@ -0,0 +1,117 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fake-or-fact/collector"
+	"fake-or-fact/repo"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const ADMIN_PATH = "/api/admin"
+const GET_RUNS_PATH = "/runs"
+const COLLECTIONS_PATH = "/collections"
+
+const defaultRunsLimit = 20
+const defaultUnhealthyRunThreshold = 3
+
+// RequireAdminToken rejects requests which do not hold the admin token as a bearer token.
+// Every request is rejected if the admin token is empty.
+func RequireAdminToken(adminToken string) func(*gin.Context) {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetRunsRoute lists the latest collection runs, along with the publishers which errored or returned nothing during
+// at least unhealthyRunThreshold consecutive runs. Unhealthy publishers are found among unhealthyRunThreshold runs per group of sources
+// even if fewer runs are listed, since every group is collected by its own runs
+func GetRunsRoute(runs repo.RunRepo, unhealthyRunThreshold int) func(*gin.Context) {
+	if unhealthyRunThreshold <= 0 {
+		unhealthyRunThreshold = defaultUnhealthyRunThreshold
+	}
+	return func(c *gin.Context) {
+		query := RunsQuery{}
+		e := c.MustBindWith(&query, binding.Query)
+		if e == nil {
+			if query.Limit <= 0 {
+				query.Limit = defaultRunsLimit
+			}
+			healthLimit := unhealthyRunThreshold * len(collector.SourceGroups())
+			if query.Limit > healthLimit {
+				healthLimit = query.Limit
+			}
+			latestRuns, err := runs.GetLatest(healthLimit)
+			if err != nil {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+			listedRuns := latestRuns
+			if len(listedRuns) > query.Limit {
+				listedRuns = listedRuns[:query.Limit]
+			}
+			c.JSON(200, RunsResponse{
+				Runs:                listedRuns,
+				UnhealthyPublishers: collector.UnhealthyPublishers(latestRuns, unhealthyRunThreshold),
+			})
+		}
+	}
+}
+
+type RunsQuery struct {
+	Limit int `form:"limit" binding:"max=500"`
+}
+
+type RunsResponse struct {
+	Runs                []repo.CollectionRun
+	UnhealthyPublishers []collector.PublisherHealth
+}
+
+// CollectionRunner allows collection runs to be started and observed
+type CollectionRunner interface {
+	Start(groups ...string) error
+	Progress() []collector.Progress
+}
+
+// StartCollectionRoute starts a collection run of the requested groups of sources (or of every group if none is requested)
+// in the background and returns the progress of the latest run of every group.
+// Responds with 409 if one of the groups is already being collected.
+func StartCollectionRoute(runner CollectionRunner) func(*gin.Context) {
+	return func(c *gin.Context) {
+		query := CollectionQuery{}
+		e := c.MustBindWith(&query, binding.Query)
+		if e == nil {
+			if err := runner.Start(query.Groups...); err != nil {
+				if err == collector.ErrRunInProgress {
+					c.JSON(http.StatusConflict, runner.Progress())
+				} else if collector.IsUnknownGroupError(err) {
+					c.AbortWithError(http.StatusBadRequest, err)
+				} else {
+					c.AbortWithError(http.StatusInternalServerError, err)
+				}
+				return
+			}
+			c.JSON(http.StatusAccepted, runner.Progress())
+		}
+	}
+}
+
+type CollectionQuery struct {
+	// the groups of sources to collect, e.g. "google"
+	Groups []string `form:"groups"`
+}
+
+// GetCollectionRoute returns the progress of the latest run of every group of sources
+func GetCollectionRoute(runner CollectionRunner) func(*gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, runner.Progress())
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fake-or-fact/collector"
+	"fake-or-fact/repo"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Test_GetRunsRoute(t *testing.T) {
+	latestRun := repo.CollectionRun{
+		StartedAt:  TIME_11_AM,
+		FinishedAt: TIME_11_AM.Add(time.Minute),
+		Publishers: []repo.PublisherRun{{Source: "google", Publisher: "a.com", Error: "timeout"}},
+	}
+	olderRun := repo.CollectionRun{
+		StartedAt:  TIME_11_AM.Add(-time.Hour),
+		FinishedAt: TIME_11_AM.Add(-time.Hour).Add(time.Minute),
+		Publishers: []repo.PublisherRun{{Source: "google", Publisher: "a.com"}},
+	}
+	tests := []struct {
+		name               string
+		requestUrl         string
+		token              string
+		expectedStatusCode int
+		expectedResponse   RunsResponse
+	}{
+		{
+			name:               "Rejects requests without the admin token",
+			requestUrl:         ADMIN_PATH + GET_RUNS_PATH,
+			token:              "",
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "Rejects requests with an invalid admin token",
+			requestUrl:         ADMIN_PATH + GET_RUNS_PATH,
+			token:              "Bearer wrong",
+			expectedStatusCode: 401,
+		},
+		{
+			name:               "Lists runs and unhealthy publishers",
+			requestUrl:         ADMIN_PATH + GET_RUNS_PATH,
+			token:              "Bearer secret",
+			expectedStatusCode: 200,
+			expectedResponse: RunsResponse{
+				Runs: []repo.CollectionRun{latestRun, olderRun},
+				UnhealthyPublishers: []collector.PublisherHealth{
+					{Source: "google", Publisher: "a.com", ConsecutiveFailures: 2, LastError: "timeout"},
+				},
+			},
+		},
+		{
+			name:               "Limits the number of runs but not the runs unhealthy publishers are found in",
+			requestUrl:         ADMIN_PATH + GET_RUNS_PATH + "?limit=1",
+			token:              "Bearer secret",
+			expectedStatusCode: 200,
+			expectedResponse: RunsResponse{
+				Runs: []repo.CollectionRun{latestRun},
+				UnhealthyPublishers: []collector.PublisherHealth{
+					{Source: "google", Publisher: "a.com", ConsecutiveFailures: 2, LastError: "timeout"},
+				},
+			},
+		},
+	}
+
+	router := gin.Default()
+	admin := router.Group(ADMIN_PATH, RequireAdminToken("secret"))
+	admin.GET(GET_RUNS_PATH, GetRunsRoute(&mockRunRepo{[]repo.CollectionRun{latestRun, olderRun}}, 2))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.requestUrl, nil)
+			req.Header.Set("Authorization", tt.token)
+			router.ServeHTTP(response, req)
+
+			actualStatusCode := response.Result().StatusCode
+			if actualStatusCode != tt.expectedStatusCode {
+				t.Errorf("HTTP Response Code = %#v, want %#v", actualStatusCode, tt.expectedStatusCode)
+			}
+			if tt.expectedStatusCode == 200 {
+				actualResponse := RunsResponse{}
+				json.Unmarshal(response.Body.Bytes(), &actualResponse)
+				if !reflect.DeepEqual(actualResponse, tt.expectedResponse) {
+					t.Errorf("Returned runs = %#v, want %#v", actualResponse, tt.expectedResponse)
+				}
+			}
+		})
+	}
+}
+
+type mockRunRepo struct {
+	// runs from latest to oldest
+	runs []repo.CollectionRun
+}
+
+func (mock *mockRunRepo) Save(run repo.CollectionRun) error {
+	mock.runs = append([]repo.CollectionRun{run}, mock.runs...)
+	return nil
+}
+
+func (mock *mockRunRepo) GetLatest(limit int) ([]repo.CollectionRun, error) {
+	if limit < len(mock.runs) {
+		return mock.runs[:limit], nil
+	}
+	return mock.runs, nil
+}
+
+func Test_CollectionRoutes(t *testing.T) {
+	tests := []struct {
+		name               string
+		method             string
+		query              string
+		runner             *mockRunner
+		expectedStatusCode int
+		expectedStarted    []string
+	}{
+		{
+			name:               "Starts a run of every group if none is in progress",
+			method:             "POST",
+			runner:             &mockRunner{},
+			expectedStatusCode: 202,
+			expectedStarted:    collector.SourceGroups(),
+		},
+		{
+			name:               "Starts a run of the requested groups",
+			method:             "POST",
+			query:              "?groups=google&groups=real-rss",
+			runner:             &mockRunner{progress: map[string]collector.Progress{"fake-rss": {Running: true, Groups: []string{"fake-rss"}}}},
+			expectedStatusCode: 202,
+			expectedStarted:    []string{"google", "real-rss"},
+		},
+		{
+			name:               "Does not start a run if one of the groups is in progress",
+			method:             "POST",
+			query:              "?groups=google",
+			runner:             &mockRunner{progress: map[string]collector.Progress{"google": {Running: true, Groups: []string{"google"}}}},
+			expectedStatusCode: 409,
+			expectedStarted:    nil,
+		},
+		{
+			name:               "Returns the progress of the current runs",
+			method:             "GET",
+			runner:             &mockRunner{progress: map[string]collector.Progress{"google": {Running: true, Groups: []string{"google"}, PublisherCount: 3}}},
+			expectedStatusCode: 200,
+			expectedStarted:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.Default()
+			admin := router.Group(ADMIN_PATH, RequireAdminToken("secret"))
+			admin.POST(COLLECTIONS_PATH, StartCollectionRoute(tt.runner))
+			admin.GET(COLLECTIONS_PATH, GetCollectionRoute(tt.runner))
+
+			response := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, ADMIN_PATH+COLLECTIONS_PATH+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer secret")
+			router.ServeHTTP(response, req)
+
+			if actualStatusCode := response.Result().StatusCode; actualStatusCode != tt.expectedStatusCode {
+				t.Errorf("HTTP Response Code = %#v, want %#v", actualStatusCode, tt.expectedStatusCode)
+			}
+			if !reflect.DeepEqual(tt.runner.started, tt.expectedStarted) {
+				t.Errorf("Started groups = %v, want %v", tt.runner.started, tt.expectedStarted)
+			}
+			actualProgress := []collector.Progress{}
+			json.Unmarshal(response.Body.Bytes(), &actualProgress)
+			if len(actualProgress) == 0 || actualProgress[0].Running != true {
+				t.Errorf("Returned progress = %#v, want a running progress", actualProgress)
+			}
+		})
+	}
+}
+
+type mockRunner struct {
+	// the progress of the latest run of each group
+	progress map[string]collector.Progress
+	started  []string
+}
+
+func (mock *mockRunner) Start(groups ...string) error {
+	if len(groups) == 0 {
+		groups = collector.SourceGroups()
+	}
+	for _, group := range groups {
+		if mock.progress[group].Running {
+			return collector.ErrRunInProgress
+		}
+	}
+	if mock.progress == nil {
+		mock.progress = make(map[string]collector.Progress)
+	}
+	for _, group := range groups {
+		mock.progress[group] = collector.Progress{Running: true, Groups: groups}
+	}
+	mock.started = groups
+	return nil
+}
+
+func (mock *mockRunner) Progress() []collector.Progress {
+	progress := make([]collector.Progress, 0, len(mock.progress))
+	for _, group := range collector.SourceGroups() {
+		if groupProgress, ok := mock.progress[group]; ok {
+			progress = append(progress, groupProgress)
+		}
+	}
+	return progress
+}
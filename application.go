@@ -1,35 +1,95 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fake-or-fact/claim"
 	. "fake-or-fact/collector"
+	"fake-or-fact/config"
 	"fake-or-fact/repo"
-	"io/ioutil"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
-	"github.com/jinzhu/gorm"
-	_ "github.com/jinzhu/gorm/dialects/postgres"
 )
 
 const GET_CLAIMS_PATH = "/api/claims"
+const GET_CLAIM_HISTORY_PATH = "/api/claims/:id/history"
+const GET_PUBLISHERS_PATH = "/api/publishers"
 
 func main() {
+	if err := runCommand(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return
+		}
+		log.Fatal(err)
+	}
+}
+
+// runServe serves the game and its API over HTTP, also collecting claims on the configured schedules if -collect is set.
+// On SIGINT or SIGTERM the server stops accepting connections, then waits for in-flight requests and collection runs to finish.
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", "", "address to listen on, e.g. :8080. Defaults to the PORT environment variable or :8080")
+	collect := flags.Bool("collect", false, "also collect claims on the configured schedules")
+	shutdownTimeout := flags.Duration("shutdown-timeout", 30*time.Second, "maximum time to wait for in-flight requests and collection runs when stopping")
+	configPath := configFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
 
-	dbConfig := loadConfig().Database
-	db, _ := gorm.Open(dbConfig.Dialect, dbConfig.ConnectionString)
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	db, err := openDatabase(ctx, config)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
-	db.AutoMigrate(&repo.ClaimData{})
-	repo := repo.NewClaimRepo(db)
+	if err := repo.Migrate(db); err != nil {
+		return fmt.Errorf("Failed to migrate database: %v", err)
+	}
+	runRepo := repo.NewRunRepo(db)
+	publisherRepo := repo.NewPublisherRepo(db)
+	if err := savePublishers(publisherRepo, config); err != nil {
+		return err
+	}
+	claimRepo := repo.NewClaimRepo(db)
 
-	initializeCollector(repo)
+	runner := newRunner(ctx, claimRepo, runRepo, repo.NewFeedCache(db), &config)
+	var collecting sync.WaitGroup
+	if *collect {
+		scheduler, err := NewScheduler(runner, &config)
+		if err != nil {
+			return fmt.Errorf("Invalid collection schedules: %v", err)
+		}
+		collecting.Add(1)
+		go func() {
+			defer collecting.Done()
+			scheduler.Run(ctx)
+		}()
+	}
 
 	r := gin.Default()
-	r.GET(GET_CLAIMS_PATH, GetClaimsRoute(repo))
+	r.GET(HEALTHZ_PATH, HealthRoute)
+	r.GET(READYZ_PATH, ReadyRoute(db.DB(), runRepo))
+	r.GET(GET_CLAIMS_PATH, GetClaimsRoute(claimRepo))
+	r.GET(GET_CLAIM_HISTORY_PATH, GetClaimHistoryRoute(claimRepo))
+	r.GET(GET_PUBLISHERS_PATH, GetPublishersRoute(publisherRepo))
+
+	admin := r.Group(ADMIN_PATH, RequireAdminToken(config.AdminToken.Value()))
+	admin.GET(GET_RUNS_PATH, GetRunsRoute(runRepo, config.UnhealthyRunThreshold))
+	admin.POST(COLLECTIONS_PATH, StartCollectionRoute(runner))
+	admin.GET(COLLECTIONS_PATH, GetCollectionRoute(runner))
 
 	r.StaticFile("/", "./public/index.html")
 	r.StaticFile("/index.html", "./public/index.html")
@@ -39,49 +99,208 @@ func main() {
 	r.Static("/css", "./public/css")
 	r.Static("/gif", "./public/gif")
 	r.Static("/img", "./public/img")
-	r.Run()
+	server := &http.Server{Addr: listenAddress(*addr), Handler: r}
+	// runs started through the admin API are waited for once the server no longer handles requests, so that none can be started while waiting
+	return serveUntilDone(ctx, server, *shutdownTimeout, func() {
+		collecting.Wait()
+		runner.Wait()
+	})
+}
+
+// listenAddress returns the given address, or the address built from the PORT environment variable or :8080 if it is empty
+func listenAddress(addr string) string {
+	if addr != "" {
+		return addr
+	}
+	if port := os.Getenv("PORT"); port != "" {
+		return ":" + port
+	}
+	return ":8080"
+}
+
+// serveUntilDone serves HTTP until the context is done, then stops accepting connections and waits for in-flight requests
+// and collection runs to finish, for at most the given timeout. waitCollections is called once in-flight requests are done
+func serveUntilDone(ctx context.Context, server *http.Server, timeout time.Duration, waitCollections func()) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("Failed to drain HTTP connections: %v", err)
+	}
+	collected := make(chan struct{})
+	go func() {
+		waitCollections()
+		close(collected)
+	}()
+	select {
+	case <-collected:
+		return nil
+	case <-shutdownCtx.Done():
+		return fmt.Errorf("Timed out waiting for collection runs to stop")
+	}
 }
 
-func GetClaimsRoute(repo repo.ClaimRepo) func(*gin.Context) {
+// GetClaimsRoute returns a page of real claims and a page of fake claims, from latest to oldest.
+// The next page is requested by passing the returned Next cursor as the cursor parameter
+func GetClaimsRoute(claims repo.ClaimRepo) func(*gin.Context) {
 	return func(c *gin.Context) {
-		reviewedAt := BeforeQuery{}
-		e := c.MustBindWith(&reviewedAt, binding.Query)
-		if e == nil {
-			if reviewedAt.Before.IsZero() {
-				reviewedAt.Before = time.Now()
+		params := ClaimsQuery{}
+		if err := c.MustBindWith(&params, binding.Query); err != nil {
+			return
+		}
+		if params.Before.IsZero() {
+			params.Before = time.Now()
+		}
+		query := repo.ClaimQuery{ReviewedBefore: params.Before, Kinds: params.Kinds, IncludeNearDuplicates: params.Duplicates, Limit: params.Limit}
+		if params.Cursor != "" {
+			cursor, err := repo.DecodeClaimCursor(params.Cursor)
+			if err != nil {
+				c.AbortWithError(http.StatusBadRequest, err)
+				return
 			}
-			facts, _ := repo.Get(true, reviewedAt.Before)
-			fakes, _ := repo.Get(false, reviewedAt.Before)
+			query.After = &cursor
+		}
+		query.IsFact = true
+		facts, err := claims.Get(query)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		query.IsFact = false
+		fakes, err := claims.Get(query)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.JSON(200, asClaimsPage(facts, fakes, repo.PageLimit(params.Limit)))
+	}
+}
+
+type ClaimsQuery struct {
+	// only claims reviewed before this time are returned, defaults to the current time
+	Before time.Time `form:"before"`
+	// the Next cursor of the previous page, the first page is returned if empty
+	Cursor string `form:"cursor"`
+	// the maximum number of claims of each truthfulness returned, defaults to repo.DefaultPageLimit and is capped to repo.MaxPageLimit
+	Limit int `form:"limit" binding:"omitempty,min=1"`
+	// only claims of these kinds are returned, e.g. "satire". Claims of any kind are returned if empty
+	Kinds []claim.Kind `form:"kind" binding:"dive,oneof=fact-check news satire"`
+	// whether every near duplicate of a claim is returned, only the latest claim of every cluster of near duplicates is returned otherwise
+	Duplicates bool `form:"duplicates"`
+}
 
-			claims := append(facts, fakes...)
-			sort.Sort(claim.Sorter{Claims: claims})
+// ClaimsPage is a page of claims returned by GetClaimsRoute
+type ClaimsPage struct {
+	Claims []claim.Claim
+	// the cursor of the next page, nil on the last page
+	Next *string
+}
 
-			c.JSON(200, claims)
+// asClaimsPage merges pages of real and fake claims of at most limit claims each.
+// A full page may end before the other one, in which case the claims of the other page which follow its last claim
+// are left for the next page so that no claim is skipped or returned twice
+func asClaimsPage(facts []claim.Claim, fakes []claim.Claim, limit int) ClaimsPage {
+	var last *repo.ClaimCursor
+	for _, claims := range [][]claim.Claim{facts, fakes} {
+		if len(claims) < limit {
+			continue
+		}
+		cursor := repo.CursorOf(claims[len(claims)-1])
+		if last == nil || cursor.Precedes(*last) {
+			last = &cursor
 		}
 	}
+	page := ClaimsPage{Claims: make([]claim.Claim, 0, len(facts)+len(fakes))}
+	for _, c := range append(append([]claim.Claim{}, facts...), fakes...) {
+		if last == nil || !last.Precedes(repo.CursorOf(c)) {
+			page.Claims = append(page.Claims, c)
+		}
+	}
+	sort.SliceStable(page.Claims, func(i, j int) bool {
+		return repo.CursorOf(page.Claims[i]).Precedes(repo.CursorOf(page.Claims[j]))
+	})
+	if last != nil {
+		next := last.Encode()
+		page.Next = &next
+	}
+	return page
 }
 
-type BeforeQuery struct {
-	Before time.Time `form:"before"`
+// GetClaimHistoryRoute returns the claim with the given ID along with the values its publisher replaced, from latest to oldest
+func GetClaimHistoryRoute(claims repo.ClaimRepo) func(*gin.Context) {
+	return func(c *gin.Context) {
+		history, err := claims.GetHistory(c.Param("id"))
+		if repo.IsClaimNotFoundError(err) {
+			c.AbortWithError(http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.JSON(200, history)
+	}
 }
 
-func initializeCollector(r repo.ClaimRepo) {
-	config := loadConfig()
-	collector := NewClaimCollector(r, &config)
-	collectorTicker := time.NewTicker(time.Duration(15) * time.Hour)
-	go func() {
-		for ; true; <-collectorTicker.C {
-			collector.CollectAndPersist()
+// GetPublishersRoute lists every known publisher ordered by name
+func GetPublishersRoute(publishers repo.PublisherRepo) func(*gin.Context) {
+	return func(c *gin.Context) {
+		allPublishers, err := publishers.GetAll()
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
 		}
-	}()
+		c.JSON(200, allPublishers)
+	}
 }
 
-func loadConfig() ClaimConfig {
-	configBytes, err := ioutil.ReadFile("claim_config.json")
+// savePublishers persists the publishers of the configured feeds so that claims can reference them.
+// Returns an error if a configured publisher is invalid, publishers which cannot be saved are only logged
+func savePublishers(publishers repo.PublisherRepo, config ClaimConfig) error {
+	configuredPublishers, err := config.Publishers()
 	if err != nil {
-		log.Panicf("Failed to load claim_config.json: %v", err)
+		return fmt.Errorf("Invalid publishers: %v", err)
+	}
+	for _, publisher := range configuredPublishers {
+		if err := publishers.Save(publisher); err != nil {
+			log.Printf("Failed to save publisher %v: %v", publisher.Name, err)
+		}
+	}
+	return nil
+}
+
+// newRunner returns a Runner collecting claims from the configured sources on demand, until the context is done
+func newRunner(ctx context.Context, r repo.ClaimRepo, runs repo.RunRepo, feeds claim.FeedCache, config *ClaimConfig) *Runner {
+	collector := NewClaimCollector(r, runs, feeds, config)
+	return NewRunner(ctx, collector)
+}
+
+const defaultConfigPath = "claim_config.json"
+
+// configFlag defines the -config flag of a command, defaulting to the FOF_CONFIG environment variable or claim_config.json
+func configFlag(flags *flag.FlagSet) *string {
+	path := defaultConfigPath
+	if envPath, isSet := os.LookupEnv("FOF_CONFIG"); isSet {
+		path = envPath
+	}
+	return flags.String("config", path, "path to the JSON configuration file")
+}
+
+// loadConfig loads and validates the configuration file at path, applying environment variable overrides and defaults
+func loadConfig(path string) (ClaimConfig, error) {
+	claimConfig := ClaimConfig{}
+	if err := config.Load(path, &claimConfig); err != nil {
+		return ClaimConfig{}, err
 	}
-	config := new(ClaimConfig)
-	json.Unmarshal(configBytes, config)
-	return *config
+	return claimConfig, nil
 }
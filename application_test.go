@@ -1,12 +1,17 @@
 package main
 
 import (
+	"crypto/md5"
 	"encoding/json"
 	"fake-or-fact/claim"
+	"fake-or-fact/collector"
 	"fake-or-fact/repo"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,10 +19,10 @@ import (
 )
 
 var TIME_11_AM = time.Date(2020, 8, 6, 11, 0, 0, 0, time.UTC)
-var TRUE_AT_11, _ = claim.NewClaim("A", "ABC", "http://abc.com", true, TIME_11_AM)
-var TRUE_AT_12, _ = claim.NewClaim("B", "BCD", "http://bcd.com", true, TIME_11_AM.Add(time.Hour))
-var FAKE_AT_10, _ = claim.NewClaim("C", "CXY", "http://cxy.com", false, TIME_11_AM.Add(-time.Hour))
-var FAKE_AT_13, _ = claim.NewClaim("D", "DDD", "http://ddd.com", false, TIME_11_AM.Add(2 * time.Hour))
+var TRUE_AT_11, _ = claim.NewClaim("A", "ABC", "http://abc.com", claim.KindNews, claim.VerdictTrue, TIME_11_AM)
+var TRUE_AT_12, _ = claim.NewClaim("B", "BCD", "http://bcd.com", claim.KindFactCheck, claim.VerdictMostlyTrue, TIME_11_AM.Add(time.Hour))
+var FAKE_AT_10, _ = claim.NewClaim("C", "CXY", "http://cxy.com", claim.KindSatire, claim.VerdictSatire, TIME_11_AM.Add(-time.Hour))
+var FAKE_AT_13, _ = claim.NewClaim("D", "DDD", "http://ddd.com", claim.KindFactCheck, claim.VerdictMisleading, TIME_11_AM.Add(2 * time.Hour))
 
 func Test_GetClaimsRoute(t *testing.T) {
 	tests := []struct {
@@ -58,6 +63,30 @@ func Test_GetClaimsRoute(t *testing.T) {
 				FAKE_AT_10,
 			},
 		},
+		{
+			name: "Filters claims by kind",
+			requestUrl: "/api/claims?kind=satire&kind=news",
+			expectedStatusCode: 200,
+			expectedClaims: []claim.Claim {
+				TRUE_AT_11,
+				FAKE_AT_10,
+			},
+		},
+		{
+			name: "Invalid kind returns bad request",
+			requestUrl: "/api/claims?kind=rumour",
+			expectedStatusCode: 400,
+		},
+		{
+			name: "Invalid cursor returns bad request",
+			requestUrl: "/api/claims?cursor=yesterday",
+			expectedStatusCode: 400,
+		},
+		{
+			name: "Invalid limit returns bad request",
+			requestUrl: "/api/claims?limit=-1",
+			expectedStatusCode: 400,
+		},
 	}
 
 	mock := &mockRepo{
@@ -72,10 +101,11 @@ func Test_GetClaimsRoute(t *testing.T) {
 		router.ServeHTTP(response, req)
 		
 		actualStatusCode := response.Result().StatusCode
-		actualClaims := []claim.Claim{}
+		page := ClaimsPage{}
 		if actualStatusCode != 400 {
-			json.Unmarshal(response.Body.Bytes(), &actualClaims)
+			json.Unmarshal(response.Body.Bytes(), &page)
 		}
+		actualClaims := page.Claims
 		if actualStatusCode != tt.expectedStatusCode {
 			t.Errorf("HTTP Response Code = %#v, want %#v", actualStatusCode, tt.expectedStatusCode)
 		}
@@ -83,10 +113,65 @@ func Test_GetClaimsRoute(t *testing.T) {
 		if tt.expectedStatusCode != 400 && !reflect.DeepEqual(actualClaims, tt.expectedClaims) {
 			t.Errorf("Returned Claims = %#v, want %#v", actualClaims, tt.expectedClaims)
 		}
+		if tt.expectedStatusCode != 400 && page.Next != nil {
+			t.Errorf("Returned Next = %v, want nil on the last page", *page.Next)
+		}
+		fields := make(map[string]json.RawMessage)
+		json.Unmarshal(response.Body.Bytes(), &fields)
+		if _, hasNext := fields["Next"]; tt.expectedStatusCode != 400 && (fields["Claims"] == nil || !hasNext) {
+			t.Errorf("Returned page = %v, want Claims and Next fields", response.Body.String())
+		}
 		})
 	}
 }
 
+func Test_GetClaimsRoute_cursor(t *testing.T) {
+	claims := repo.NewMemoryClaimRepo()
+	var saved []string
+	// facts and fakes reviewed at the same time, which span several pages
+	for i, verdict := range []claim.Verdict{claim.VerdictTrue, claim.VerdictFalse, claim.VerdictTrue, claim.VerdictFalse, claim.VerdictTrue, claim.VerdictTrue, claim.VerdictFalse} {
+		reviewedAt := TIME_11_AM
+		if i == 0 {
+			reviewedAt = TIME_11_AM.Add(time.Hour)
+		}
+		c, _ := claim.NewClaim(fmt.Sprintf("%x", md5.Sum([]byte{byte(i)})), "ABC", fmt.Sprintf("http://abc.com/%v", i), claim.KindNews, verdict, reviewedAt)
+		claims.Save(c, repo.KeepStored)
+		saved = append(saved, c.URL)
+	}
+	router := setupRouter(claims)
+
+	seen := make(map[string]int)
+	var previous *repo.ClaimCursor
+	requestUrl := "/api/claims?limit=2"
+	for pages := 0; pages < len(saved); pages++ {
+		response := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", requestUrl, nil)
+		router.ServeHTTP(response, req)
+		if response.Result().StatusCode != 200 {
+			t.Fatalf("HTTP Response Code = %v, want 200", response.Result().StatusCode)
+		}
+		page := ClaimsPage{}
+		json.Unmarshal(response.Body.Bytes(), &page)
+		for _, c := range page.Claims {
+			seen[c.URL]++
+			cursor := repo.CursorOf(c)
+			if previous != nil && !previous.Precedes(cursor) {
+				t.Errorf("Returned claim %v reviewed at %v before the previous claim", c.URL, c.ReviewedAt)
+			}
+			previous = &cursor
+		}
+		if page.Next == nil {
+			break
+		}
+		requestUrl = "/api/claims?limit=2&cursor=" + url.QueryEscape(*page.Next)
+	}
+	for _, savedURL := range saved {
+		if seen[savedURL] != 1 {
+			t.Errorf("Claim %v was returned %v times, want once across pages %v", savedURL, seen[savedURL], seen)
+		}
+	}
+}
+
 
 func setupRouter(repo repo.ClaimRepo) *gin.Engine {
 	router := gin.Default()
@@ -99,28 +184,159 @@ type mockRepo struct {
 	fakeClaims []claim.Claim
 }
 
-func (mock *mockRepo) Save(claim claim.Claim) error {
+func (mock *mockRepo) Save(claim claim.Claim, onConflict repo.OnConflict) (repo.SaveResult, error) {
 	if claim.IsFact {
 		mock.trueClaims = append(mock.trueClaims, claim) 
 	} else {
 		mock.fakeClaims = append(mock.fakeClaims, claim) 
 	}
+	return repo.ClaimInserted, nil
+}
+
+func (mock *mockRepo) SaveBatch(claims []claim.Claim, onConflict repo.OnConflict) ([]repo.SaveResult, error) {
+	return nil, nil
+}
+
+func (mock *mockRepo) Export(filter repo.ExportFilter, each func(claim.Claim) error) error {
 	return nil
 }
 
-func (mock *mockRepo) Get(isFact bool, reviewedBefore time.Time) ([]claim.Claim, error) {
+func (mock *mockRepo) GetHistory(id string) (repo.ClaimHistory, error) {
+	return repo.ClaimHistory{}, nil
+}
+
+func (mock *mockRepo) Get(query repo.ClaimQuery) ([]claim.Claim, error) {
 	toReturn := []claim.Claim{}
 	claimsToIterateOver := []claim.Claim{}
-	if isFact {
+	if query.IsFact {
 		claimsToIterateOver = mock.trueClaims
 	} else {
 		claimsToIterateOver = mock.fakeClaims
 	}
 
 	for _, claim := range claimsToIterateOver {
-		if claim.ReviewedAt.Before(reviewedBefore) {
+		if claim.ReviewedAt.Before(query.ReviewedBefore) && (len(query.Kinds) == 0 || containsKind(query.Kinds, claim.Kind)) {
 			toReturn = append(toReturn, claim)
 		}
 	}
 	return toReturn, nil
-}
\ No newline at end of file
+}
+
+func containsKind(kinds []claim.Kind, kind claim.Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+func Test_GetClaimHistoryRoute(t *testing.T) {
+	claims := repo.NewMemoryClaimRepo()
+	claims.Save(FAKE_AT_13, repo.KeepStored)
+	revised := FAKE_AT_13
+	revised.Verdict = claim.VerdictMostlyTrue
+	revised.IsFact = true
+	claims.Save(revised, repo.UpdateStored)
+	stored, _ := claims.Get(repo.ClaimQuery{IsFact: true, ReviewedBefore: TIME_11_AM.Add(24 * time.Hour)})
+	router := gin.Default()
+	router.GET(GET_CLAIM_HISTORY_PATH, GetClaimHistoryRoute(claims))
+
+	tests := []struct {
+		name               string
+		id                 string
+		expectedStatusCode int
+	}{
+		{
+			name:               "Returns the claim along with its revisions",
+			id:                 stored[0].ID,
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Unknown claims are not found",
+			id:                 "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+			expectedStatusCode: 404,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/claims/"+tt.id+"/history", nil)
+			router.ServeHTTP(response, req)
+
+			if actualStatusCode := response.Result().StatusCode; actualStatusCode != tt.expectedStatusCode {
+				t.Fatalf("HTTP Response Code = %#v, want %#v", actualStatusCode, tt.expectedStatusCode)
+			}
+			if tt.expectedStatusCode != 200 {
+				return
+			}
+			history := repo.ClaimHistory{}
+			json.Unmarshal(response.Body.Bytes(), &history)
+			if history.Claim.ID != tt.id || history.Claim.Verdict != claim.VerdictMostlyTrue {
+				t.Errorf("Returned claim = %#v, want the revised claim %v", history.Claim, tt.id)
+			}
+			if len(history.Revisions) != 1 || history.Revisions[0].Verdict != claim.VerdictMisleading {
+				t.Errorf("Returned revisions = %#v, want the misleading verdict", history.Revisions)
+			}
+		})
+	}
+}
+
+func Test_GetPublishersRoute(t *testing.T) {
+	publishers := []claim.Publisher{
+		{Name: "ABC", Homepage: "http://abc.com", TrustLabel: "mainstream"},
+		{Name: "CXY", Homepage: "http://cxy.com", TrustLabel: "satire"},
+	}
+	router := gin.Default()
+	router.GET(GET_PUBLISHERS_PATH, GetPublishersRoute(&mockPublisherRepo{publishers}))
+
+	response := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", GET_PUBLISHERS_PATH, nil)
+	router.ServeHTTP(response, req)
+
+	if actualStatusCode := response.Result().StatusCode; actualStatusCode != 200 {
+		t.Errorf("HTTP Response Code = %#v, want %#v", actualStatusCode, 200)
+	}
+	actualPublishers := []claim.Publisher{}
+	json.Unmarshal(response.Body.Bytes(), &actualPublishers)
+	if !reflect.DeepEqual(actualPublishers, publishers) {
+		t.Errorf("Returned Publishers = %#v, want %#v", actualPublishers, publishers)
+	}
+}
+
+type mockPublisherRepo struct {
+	publishers []claim.Publisher
+}
+
+func (mock *mockPublisherRepo) Save(publisher claim.Publisher) error {
+	mock.publishers = append(mock.publishers, publisher)
+	return nil
+}
+
+func (mock *mockPublisherRepo) GetAll() ([]claim.Publisher, error) {
+	return mock.publishers, nil
+}
+
+func Test_savePublishers(t *testing.T) {
+	daily := claim.Publisher{Name: "Daily", Homepage: "https://daily.com"}
+	tests := []struct {
+		name    string
+		feeds   []collector.RssFeed
+		want    []claim.Publisher
+		wantErr bool
+	}{
+		{name: "saves the publishers of the feeds", feeds: []collector.RssFeed{{URL: "https://daily.com/feed", Publisher: daily}, {URL: "https://other.com/feed"}}, want: []claim.Publisher{daily}},
+		{name: "returns an error for invalid publishers", feeds: []collector.RssFeed{{URL: "https://daily.com/feed", Publisher: claim.Publisher{Name: strings.Repeat("a", claim.MaxPublisherNameLength+1)}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publishers := &mockPublisherRepo{}
+			config := collector.ClaimConfig{RealRssFeeds: tt.feeds}
+			if err := savePublishers(publishers, config); (err != nil) != tt.wantErr {
+				t.Fatalf("savePublishers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(publishers.publishers, tt.want) {
+				t.Errorf("Saved publishers = %v, want %v", publishers.publishers, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package claim
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URLRules configures how the URLs of claims are canonicalized, so that the URLs of the same article are equal
+type URLRules struct {
+	// query parameters removed from URLs in addition to DefaultStrippedParams, a trailing * matching any suffix,
+	// e.g. "ref", "share" or "amp" for a site which does not identify articles by them
+	StripParams []string
+	// query parameters which are kept even if they match DefaultStrippedParams or StripParams, e.g. "cmpid" for a site identifying articles by it
+	KeepParams []string
+	// true to keep the scheme of http URLs instead of upgrading them to https
+	KeepScheme bool
+	// true to keep the AMP variants of articles instead of replacing them by the canonical article
+	KeepAMP bool
+}
+
+// DefaultStrippedParams are the tracking query parameters removed from every URL unless they are kept by URLRules.KeepParams.
+// Parameters which some sites use to identify articles, such as "ref", "share" or "amp", are only removed through URLRules.StripParams
+func DefaultStrippedParams() []string {
+	return []string{"utm_*", "fbclid", "gclid", "dclid", "msclkid", "yclid", "mc_cid", "mc_eid", "_ga", "igshid", "ref_src", "cmpid", "ocid", "ncid"}
+}
+
+// URLCanonicalizer rewrites the URLs of claims into their canonical form
+type URLCanonicalizer struct {
+	rules URLRules
+	strip []string
+	keep  []string
+}
+
+// NewURLCanonicalizer returns a canonicalizer applying the rules along with DefaultStrippedParams.
+// Returns an error if a parameter pattern is empty or has a * anywhere but at its end
+func NewURLCanonicalizer(rules URLRules) (URLCanonicalizer, error) {
+	strip := append(DefaultStrippedParams(), rules.StripParams...)
+	for _, pattern := range append(append([]string{}, strip...), rules.KeepParams...) {
+		if pattern == "" || strings.Contains(strings.TrimSuffix(pattern, "*"), "*") {
+			return URLCanonicalizer{}, fmt.Errorf("Invalid URL parameter pattern '%v', a * is only allowed at its end", pattern)
+		}
+	}
+	return URLCanonicalizer{rules: rules, strip: strip, keep: rules.KeepParams}, nil
+}
+
+// Canonicalize returns the canonical form of an absolute http(s) URL:
+//   - the scheme and host are lowercased, the default port and the fragment are removed and http is upgraded to https
+//   - AMP variants are replaced by their article, e.g. for amp.site.com, /article/amp, /article.amp.html or Google's AMP cache
+//   - stripped query parameters are removed and the remaining ones are sorted
+//   - trailing slashes are removed from the path
+//
+// Other URLs are returned unchanged, apart from surrounding whitespace
+func (canonicalizer URLCanonicalizer) Canonicalize(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || parsed.Opaque != "" {
+		return rawURL
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return rawURL
+	}
+	if !canonicalizer.rules.KeepAMP {
+		parsed = withoutAMP(parsed)
+	}
+	if !canonicalizer.rules.KeepScheme {
+		parsed.Scheme = "https"
+	}
+	host, port := strings.ToLower(parsed.Hostname()), parsed.Port()
+	if port == "" || port == "80" || port == "443" {
+		parsed.Host = host
+	} else {
+		parsed.Host = host + ":" + port
+	}
+	parsed.Fragment = ""
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	parsed.RawPath = ""
+
+	query := parsed.Query()
+	for name := range query {
+		if canonicalizer.strips(name) {
+			query.Del(name)
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	parsed.ForceQuery = false
+	return parsed.String()
+}
+
+// CanonicalizeClaim returns the claim with its URL and the URLs of its reviews canonicalized,
+// keeping the URL it was collected with as its OriginalURL as described by OriginalURLOf
+func (canonicalizer URLCanonicalizer) CanonicalizeClaim(c Claim) Claim {
+	canonicalURL := canonicalizer.Canonicalize(c.URL)
+	c.OriginalURL = OriginalURLOf(c.OriginalURL, c.URL, canonicalURL)
+	c.URL = canonicalURL
+	if len(c.Reviews) > 0 {
+		reviews := make([]Review, 0, len(c.Reviews))
+		for _, review := range c.Reviews {
+			review.URL = canonicalizer.Canonicalize(review.URL)
+			reviews = append(reviews, review)
+		}
+		c.Reviews = reviews
+	}
+	return c
+}
+
+// OriginalURLOf returns the original URL of a claim whose URL is replaced by canonicalURL: the original URL it already has if any,
+// otherwise its URL if it differs from canonicalURL, and an empty string if its URL was already canonical
+func OriginalURLOf(originalURL string, url string, canonicalURL string) string {
+	if originalURL == "" && url != canonicalURL {
+		return url
+	}
+	return originalURL
+}
+
+// strips returns true if the query parameter matches a stripped pattern and no kept pattern
+func (canonicalizer URLCanonicalizer) strips(param string) bool {
+	return matchesAnyParam(canonicalizer.strip, param) && !matchesAnyParam(canonicalizer.keep, param)
+}
+
+// matchesAnyParam returns true if the query parameter matches one of the patterns, ignoring case
+func matchesAnyParam(patterns []string, param string) bool {
+	param = strings.ToLower(param)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if prefix := strings.TrimSuffix(pattern, "*"); prefix != pattern {
+			if strings.HasPrefix(param, prefix) {
+				return true
+			}
+		} else if param == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutAMP returns the URL of the article an AMP variant was generated from
+func withoutAMP(parsed *url.URL) *url.URL {
+	host := strings.ToLower(parsed.Hostname())
+	// Google's AMP cache serves https://site.com/article from https://site-com.cdn.ampproject.org/c/s/site.com/article
+	if strings.HasSuffix(host, ".cdn.ampproject.org") {
+		scheme, path := "http", ""
+		if strings.HasPrefix(parsed.Path, "/c/s/") {
+			scheme, path = "https", strings.TrimPrefix(parsed.Path, "/c/s/")
+		} else if strings.HasPrefix(parsed.Path, "/c/") {
+			path = strings.TrimPrefix(parsed.Path, "/c/")
+		}
+		if path != "" {
+			if article, err := url.Parse(scheme + "://" + path); err == nil && article.Host != "" {
+				article.RawQuery = parsed.RawQuery
+				return withoutAMP(article)
+			}
+		}
+		return parsed
+	}
+	if strings.HasPrefix(host, "amp.") {
+		parsed.Host = parsed.Host[len("amp."):]
+	}
+	path := strings.TrimRight(parsed.Path, "/")
+	switch {
+	case strings.HasSuffix(path, "/amp"):
+		path = strings.TrimSuffix(path, "/amp")
+	case strings.HasSuffix(path, ".amp.html"):
+		path = strings.TrimSuffix(path, ".amp.html") + ".html"
+	case strings.HasSuffix(path, ".amp"):
+		path = strings.TrimSuffix(path, ".amp")
+	case strings.HasPrefix(path, "/amp/"):
+		path = strings.TrimPrefix(path, "/amp")
+	}
+	parsed.Path = path
+	return parsed
+}
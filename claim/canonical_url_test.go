@@ -0,0 +1,162 @@
+package claim
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestURLCanonicalizer_Canonicalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules URLRules
+		url   string
+		want  string
+	}{
+		{
+			name: "tracking parameters are removed and the others sorted",
+			url:  "https://checker.com/claim?utm_source=rss&utm_medium=feed&id=2&fbclid=abc&a=1",
+			want: "https://checker.com/claim?a=1&id=2",
+		},
+		{
+			name: "http is upgraded to https",
+			url:  "http://checker.com/claim",
+			want: "https://checker.com/claim",
+		},
+		{
+			name: "the scheme and host are lowercased and the default port removed",
+			url:  "HTTPS://Checker.COM:443/Claim",
+			want: "https://checker.com/Claim",
+		},
+		{
+			name: "other ports are kept",
+			url:  "https://checker.com:8080/claim",
+			want: "https://checker.com:8080/claim",
+		},
+		{
+			name: "trailing slashes and fragments are removed",
+			url:  "https://checker.com/claim//#comments",
+			want: "https://checker.com/claim",
+		},
+		{
+			name: "the root path is removed",
+			url:  "https://checker.com/",
+			want: "https://checker.com",
+		},
+		{
+			name: "amp subdomains are removed",
+			url:  "https://amp.checker.com/claim",
+			want: "https://checker.com/claim",
+		},
+		{
+			name: "amp path suffixes are removed",
+			url:  "https://checker.com/claim/amp/",
+			want: "https://checker.com/claim",
+		},
+		{
+			name: "amp html extensions are replaced",
+			url:  "https://checker.com/claim.amp.html",
+			want: "https://checker.com/claim.html",
+		},
+		{
+			name: "amp path prefixes are removed",
+			url:  "https://checker.com/amp/claim",
+			want: "https://checker.com/claim",
+		},
+		{
+			name: "Google's AMP cache is replaced by the article",
+			url:  "https://checker-com.cdn.ampproject.org/c/s/checker.com/claim/amp?utm_source=amp&id=2",
+			want: "https://checker.com/claim?id=2",
+		},
+		{
+			name: "parameters which may identify articles are kept by default",
+			url:  "https://checker.com/claim?share=42&ref=home&amp=1",
+			want: "https://checker.com/claim?amp=1&ref=home&share=42",
+		},
+		{
+			name:  "configured parameters are removed",
+			rules: URLRules{StripParams: []string{"ref", "ref_*", "page"}},
+			url:   "https://checker.com/claim?ref=home&ref_feed=rss&page=1&id=2",
+			want:  "https://checker.com/claim?id=2",
+		},
+		{
+			name:  "kept parameters override stripped ones",
+			rules: URLRules{StripParams: []string{"share"}, KeepParams: []string{"cmpid", "share"}},
+			url:   "https://checker.com/claim?share=42&cmpid=7&utm_source=rss",
+			want:  "https://checker.com/claim?cmpid=7&share=42",
+		},
+		{
+			name:  "the scheme and amp variants can be kept",
+			rules: URLRules{KeepScheme: true, KeepAMP: true},
+			url:   "http://amp.checker.com/claim/amp",
+			want:  "http://amp.checker.com/claim/amp",
+		},
+		{
+			name: "relative URLs are unchanged",
+			url:  " /claim?utm_source=rss ",
+			want: "/claim?utm_source=rss",
+		},
+		{
+			name: "other schemes are unchanged",
+			url:  "ftp://checker.com/claim/",
+			want: "ftp://checker.com/claim/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canonicalizer, err := NewURLCanonicalizer(tt.rules)
+			if err != nil {
+				t.Fatalf("NewURLCanonicalizer() error = %v", err)
+			}
+			if got := canonicalizer.Canonicalize(tt.url); got != tt.want {
+				t.Errorf("Canonicalize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewURLCanonicalizer(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   URLRules
+		wantErr bool
+	}{
+		{name: "prefix patterns are valid", rules: URLRules{StripParams: []string{"ref_*"}, KeepParams: []string{"id"}}},
+		{name: "empty patterns are invalid", rules: URLRules{StripParams: []string{""}}, wantErr: true},
+		{name: "inner wildcards are invalid", rules: URLRules{KeepParams: []string{"utm_*_id"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewURLCanonicalizer(tt.rules); (err != nil) != tt.wantErr {
+				t.Errorf("NewURLCanonicalizer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestURLCanonicalizer_CanonicalizeClaim(t *testing.T) {
+	canonicalizer, _ := NewURLCanonicalizer(URLRules{})
+	collected := Claim{
+		URL:     "http://checker.com/claim/?utm_source=rss",
+		Reviews: []Review{{URL: "http://checker.com/claim/"}},
+	}
+
+	got := canonicalizer.CanonicalizeClaim(collected)
+	want := Claim{
+		URL:         "https://checker.com/claim",
+		OriginalURL: "http://checker.com/claim/?utm_source=rss",
+		Reviews:     []Review{{URL: "https://checker.com/claim"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CanonicalizeClaim() = %+v, want %+v", got, want)
+	}
+	if collected.Reviews[0].URL != "http://checker.com/claim/" {
+		t.Errorf("CanonicalizeClaim() modified the reviews of the collected claim")
+	}
+	if again := canonicalizer.CanonicalizeClaim(got); !reflect.DeepEqual(again, want) {
+		t.Errorf("CanonicalizeClaim() of a canonicalized claim = %+v, want %+v", again, want)
+	}
+	canonical := Claim{URL: "https://checker.com/claim"}
+	if got := canonicalizer.CanonicalizeClaim(canonical); !reflect.DeepEqual(got, canonical) {
+		t.Errorf("CanonicalizeClaim() of a claim collected with its canonical URL = %+v, want %+v", got, canonical)
+	}
+}
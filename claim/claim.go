@@ -1,6 +1,7 @@
 package claim
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -10,21 +11,39 @@ import (
 
 // Claim represents a fact or a fake claim according to a certain publisher
 type Claim struct {
+	// the identifier of the stored claim, empty if the claim is not stored
+	ID string
+	// the identifier shared by the stored claims whose titles are near duplicates, empty if the claim is not stored
+	ClusterID string
 	Title string
 	PublisherName string
 	// the url to the article evaluating the claim
 	URL string
-	// true if the claim is a fact, false if it is fake
+	// the url the claim was collected with, before it was canonicalized into URL. Empty if the claim's URL was not canonicalized
+	OriginalURL string
+	// the origin of the claim, e.g. a fact-check review or a satire headline
+	Kind Kind
+	// the rating given to the claim by its publisher
+	Verdict Verdict
+	// true if the claim is a fact, false if it is fake. Derived from the Verdict
 	IsFact bool
 	// the time at which this claim appeared 
 	ReviewedAt time.Time
+	// the person or organization who made the claim, if known
+	Claimant string
+	// the time at which the claim was made, zero if unknown
+	ClaimDate time.Time
+	// the url of a page where the claim appeared, empty if unknown
+	AppearanceURL string
+	// every review of the claim, including the one the claim's URL and Verdict are based on. Empty if the claim was not fact-checked
+	Reviews []Review
 }
 
 const invalidParamMsgFormat string = "Cannot create claim with invalid parameter %v: %v"
 
 // NewClaim attempts to construct a claim based on the passed parameters. Returns an error on failure.
-// The title's first letter is also capitalized if possible.
-func NewClaim(title string, publisherName string, url string, isFact bool, reviewedAt time.Time) (Claim, error) {
+// The title's first letter is also capitalized if possible, and IsFact is derived from the verdict.
+func NewClaim(title string, publisherName string, url string, kind Kind, verdict Verdict, reviewedAt time.Time) (Claim, error) {
 	if title == "" {
 		return Claim{}, fmt.Errorf(invalidParamMsgFormat, "title", title) 
 	}
@@ -34,6 +53,12 @@ func NewClaim(title string, publisherName string, url string, isFact bool, revie
 	if url == "" {
 		return Claim{}, fmt.Errorf(invalidParamMsgFormat, "url", url) 
 	}
+	if !kind.IsValid() {
+		return Claim{}, fmt.Errorf(invalidParamMsgFormat, "kind", kind)
+	}
+	if !verdict.IsValid() {
+		return Claim{}, fmt.Errorf(invalidParamMsgFormat, "verdict", verdict)
+	}
 	if reviewedAt.IsZero() {
 		return Claim{}, fmt.Errorf(invalidParamMsgFormat, "reviewedAt", reviewedAt) 
 	}
@@ -43,7 +68,9 @@ func NewClaim(title string, publisherName string, url string, isFact bool, revie
 		Title:          title,
 		URL:           url,
 		PublisherName: publisherName,
-		IsFact:        isFact,
+		Kind:          kind,
+		Verdict:       verdict,
+		IsFact:        verdict.IsFact(),
 		ReviewedAt:    reviewedAt,
 	}
 
@@ -57,8 +84,9 @@ func (c Claim) ReferencesVisuals() bool {
 }
 
 // Source enables the retrieval of Claims for a given publisher. The publisher could be a URL or domain depending on the implementation
+// The context can be cancelled to stop collecting claims early. SourceV2 should be preferred since it reports failures.
 type Source interface {
-	GetClaims(publisher string) []Claim
+	GetClaims(ctx context.Context, publisher string) []Claim
 }
 
 func capitalizeFirstLetter(oldTitle string) string {
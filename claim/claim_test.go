@@ -11,7 +11,8 @@ func Test_NewClaim(t *testing.T) {
 		title          string
 		publisherName string
 		url           string
-		isFact        bool
+		kind          Kind
+		verdict       Verdict
 		reviewedAt    time.Time
 	}
 	tests := []struct {
@@ -22,22 +23,22 @@ func Test_NewClaim(t *testing.T) {
 	}{
 		{
 			name: "Empty title returns error",
-			args: args{ title: "", publisherName: "p", url: "u", isFact: true, reviewedAt: time.Now() },
+			args: args{ title: "", publisherName: "p", url: "u", kind: KindFactCheck, verdict: VerdictTrue, reviewedAt: time.Now() },
 			wantErr: true,
 		},
 		{
 			name: "Empty publisherName returns error",
-			args: args{ publisherName: "", title: "t", url: "u", isFact: true, reviewedAt: time.Now() },
+			args: args{ publisherName: "", title: "t", url: "u", kind: KindFactCheck, verdict: VerdictTrue, reviewedAt: time.Now() },
 			wantErr: true,
 		},
 		{
 			name: "Empty url returns error",
-			args: args{ url: "", title: "t", publisherName: "p", isFact: true, reviewedAt: time.Now() },
+			args: args{ url: "", title: "t", publisherName: "p", kind: KindFactCheck, verdict: VerdictTrue, reviewedAt: time.Now() },
 			wantErr: true,
 		},
 		{
 			name: "Zero time returns error",
-			args: args{reviewedAt: time.Time{}, title: "t", publisherName: "p", url: "u", isFact: true },
+			args: args{reviewedAt: time.Time{}, title: "t", publisherName: "p", url: "u", kind: KindFactCheck, verdict: VerdictTrue },
 			wantErr: true,
 		},
 		{
@@ -46,7 +47,8 @@ func Test_NewClaim(t *testing.T) {
 				title: "'first letter of title is capitalized.'",
 				publisherName: "Publisher Name",
 				url: "http://url.com",
-				isFact: true,
+				kind: KindFactCheck,
+				verdict: VerdictTrue,
 				reviewedAt: time.Date(2020, 8, 6, 23, 20, 42, 0, time.UTC),
 			},
 			wantErr: false,
@@ -54,14 +56,47 @@ func Test_NewClaim(t *testing.T) {
 				Title: "'First letter of title is capitalized.'",
 				PublisherName: "Publisher Name",
 				URL: "http://url.com",
+				Kind: KindFactCheck,
+				Verdict: VerdictTrue,
 				IsFact: true,
 				ReviewedAt: time.Date(2020, 8, 6, 23, 20, 42, 0, time.UTC),
 			},
 		},
+		{
+			name: "Invalid kind returns error",
+			args: args{ kind: "rumour", title: "t", publisherName: "p", url: "u", verdict: VerdictTrue, reviewedAt: time.Now() },
+			wantErr: true,
+		},
+		{
+			name: "Invalid verdict returns error",
+			args: args{ kind: KindFactCheck, verdict: "maybe", title: "t", publisherName: "p", url: "u", reviewedAt: time.Now() },
+			wantErr: true,
+		},
+		{
+			name: "Derives IsFact from verdict",
+			args: args{
+				title: "Title",
+				publisherName: "Publisher Name",
+				url: "http://url.com",
+				kind: KindFactCheck,
+				verdict: VerdictMostlyFalse,
+				reviewedAt: time.Date(2020, 8, 6, 23, 20, 42, 0, time.UTC),
+			},
+			wantErr: false,
+			want: Claim {
+				Title: "Title",
+				PublisherName: "Publisher Name",
+				URL: "http://url.com",
+				Kind: KindFactCheck,
+				Verdict: VerdictMostlyFalse,
+				IsFact: false,
+				ReviewedAt: time.Date(2020, 8, 6, 23, 20, 42, 0, time.UTC),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewClaim(tt.args.title, tt.args.publisherName, tt.args.url, tt.args.isFact, tt.args.reviewedAt)
+			got, err := NewClaim(tt.args.title, tt.args.publisherName, tt.args.url, tt.args.kind, tt.args.verdict, tt.args.reviewedAt)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewClaim() error = %v, wantErr %v", err, tt.wantErr)
 				return
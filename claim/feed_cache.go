@@ -0,0 +1,14 @@
+package claim
+
+// FeedValidators are the validators returned along with a feed, sent back on the next request so that unchanged feeds are not downloaded again
+type FeedValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// FeedCache persists the validators of feeds, keyed by feed URL
+type FeedCache interface {
+	// GetValidators returns the validators stored for the feed URL, or empty validators if none are stored
+	GetValidators(feedURL string) (FeedValidators, error)
+	SaveValidators(feedURL string, validators FeedValidators) error
+}
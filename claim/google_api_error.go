@@ -0,0 +1,79 @@
+package claim
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiError is returned when the google fact-check API responds with a failure status code
+type apiError struct {
+	statusCode int
+	// the beginning of the response body, used to tell invalid API keys apart from other bad requests
+	message string
+	// the delay requested by the API through the Retry-After header, zero if absent
+	retryAfter time.Duration
+}
+
+func (e apiError) Error() string {
+	return fmt.Sprintf("Encountered invalid response code while querying claims API: %v %v", e.statusCode, e.message)
+}
+
+// transportError is returned when a request to the google fact-check API fails without a response, e.g. on timeouts
+type transportError struct {
+	err error
+}
+
+func (e transportError) Error() string {
+	return fmt.Sprintf("Failed to reach claims API: %v", e.err)
+}
+
+func (e transportError) Unwrap() error {
+	return e.err
+}
+
+// IsAuthError returns true if the error was caused by the google fact-check API rejecting the API key
+func IsAuthError(err error) bool {
+	var apiErr apiError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return true
+	case http.StatusBadRequest:
+		// google responds to invalid API keys with a 400 status code
+		return strings.Contains(apiErr.message, "API_KEY_INVALID") || strings.Contains(apiErr.message, "API key not valid")
+	default:
+		return false
+	}
+}
+
+// IsTransientError returns true if the error is a failure of the google fact-check API which might not occur if the request is retried,
+// such as rate limiting, server errors and timeouts
+func IsTransientError(err error) bool {
+	var apiErr apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.statusCode == http.StatusTooManyRequests || apiErr.statusCode >= 500
+	}
+	var transportErr transportError
+	return errors.As(err, &transportErr)
+}
+
+// parseRetryAfter returns the delay requested by a Retry-After header, expressed either in seconds or as an HTTP date.
+// Returns zero if the header is absent or invalid.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
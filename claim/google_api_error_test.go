@@ -0,0 +1,73 @@
+package claim
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 8, 6, 11, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+	}{
+		{name: "Missing header returns zero", header: "", want: 0},
+		{name: "Parses delay in seconds", header: "120", want: 2 * time.Minute},
+		{name: "Parses HTTP date", header: "Thu, 06 Aug 2020 11:00:30 GMT", want: 30 * time.Second},
+		{name: "Date in the past returns zero", header: "Thu, 06 Aug 2020 10:00:00 GMT", want: 0},
+		{name: "Invalid header returns zero", header: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.header, now); got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_IsAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Forbidden is an auth error", err: apiError{statusCode: 403}, want: true},
+		{name: "Bad request for an invalid key is an auth error", err: apiError{statusCode: 400, message: `"reason": "API_KEY_INVALID"`}, want: true},
+		{name: "Other bad requests are not auth errors", err: apiError{statusCode: 400, message: "Invalid page token"}, want: false},
+		{name: "Wrapped auth errors are auth errors", err: fmt.Errorf("publisher.com: %w", apiError{statusCode: 401}), want: true},
+		{name: "Server errors are not auth errors", err: apiError{statusCode: 500}, want: false},
+		{name: "Nil is not an auth error", err: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAuthError(tt.err); got != tt.want {
+				t.Errorf("IsAuthError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_IsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Rate limiting is transient", err: apiError{statusCode: 429}, want: true},
+		{name: "Server errors are transient", err: apiError{statusCode: 503}, want: true},
+		{name: "Transport errors are transient", err: transportError{errors.New("timeout")}, want: true},
+		{name: "Client errors are not transient", err: apiError{statusCode: 404}, want: false},
+		{name: "Other errors are not transient", err: errors.New("invalid JSON"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransientError(tt.err); got != tt.want {
+				t.Errorf("IsTransientError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
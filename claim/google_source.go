@@ -1,110 +1,312 @@
 package claim
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"net/http"
-	"regexp"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // GoogleSource is a claim source based on the google fact-check API
 type GoogleSource struct {
 	api claimAPI
+	// maps the textual ratings of claim reviews to verdicts
+	classifier RatingClassifier
+	// the query settings of each publisher. Publishers without settings are queried with the DefaultGoogleQuery
+	queries map[string]GoogleQuery
 }
 
-// NewGoogleSource creates a claim source based on the google fact-check API
-func NewGoogleSource(apiKey string) *GoogleSource {
-	return &GoogleSource{newClaimAPI(apiKey)}
+// GoogleQuery configures how the claims of a publisher are queried from the google fact-check API
+type GoogleQuery struct {
+	// the language of the claims to retrieve, e.g. "en-US". All languages are retrieved if empty
+	LanguageCode string
+	// the number of claims requested per page
+	PageSize int
+	// only claims reviewed in the last MaxAgeDays days are retrieved
+	MaxAgeDays int
+	// the maximum number of pages requested per publisher
+	MaxPages int
+	// the maximum number of claims retrieved per publisher
+	MaxClaims int
+}
+
+// DefaultGoogleQuery returns the query settings used for publishers without settings of their own
+func DefaultGoogleQuery() GoogleQuery {
+	return GoogleQuery{LanguageCode: "en-US", PageSize: 100, MaxAgeDays: 20, MaxPages: 5, MaxClaims: 500}
+}
+
+// withDefaults returns a copy of the query where numeric settings left empty are set to their default value
+func (query GoogleQuery) withDefaults() GoogleQuery {
+	defaults := DefaultGoogleQuery()
+	if query.PageSize <= 0 {
+		query.PageSize = defaults.PageSize
+	}
+	if query.MaxAgeDays <= 0 {
+		query.MaxAgeDays = defaults.MaxAgeDays
+	}
+	if query.MaxPages <= 0 {
+		query.MaxPages = defaults.MaxPages
+	}
+	if query.MaxClaims <= 0 {
+		query.MaxClaims = defaults.MaxClaims
+	}
+	return query
+}
+
+// NewGoogleSource creates a claim source based on the google fact-check API.
+// queries holds the query settings of each publisher site, and may be nil.
+func NewGoogleSource(apiKey string, classifier RatingClassifier, queries map[string]GoogleQuery) *GoogleSource {
+	return &GoogleSource{newClaimAPI(apiKey), classifier, queries}
 }
 
 // GetClaims returns a slice of Claims that could be parsed for the given publisher
-func (googleSource *GoogleSource) GetClaims(publisher string) []Claim {
-	claims := make([]Claim, 0)
-	claimResponse, apiErr := googleSource.api.getClaims(publisher)
-	if apiErr != nil {
-		log.Printf("Encountered error while collecting claims for publisher %s: %s", publisher, apiErr.Error())
+func (googleSource *GoogleSource) GetClaims(ctx context.Context, publisher string) []Claim {
+	result, err := googleSource.FetchClaims(ctx, publisher)
+	if IsAuthError(err) {
+		log.Printf("Google fact-check API key was rejected: %v", err)
 	} else {
-		for _, claimDto := range claimResponse.Claims {
-			if len(claimDto.ClaimReview) > 0 {
-				claimReview := claimDto.ClaimReview[0]
-				isFact, err := parseTextualRating(claimReview.TextualRating)
-				if err == nil {
-
-					claim, creationErr := NewClaim(claimDto.Text, claimReview.Publisher.Name, claimReview.URL, isFact, claimReview.ReviewDate)
-					if creationErr == nil {
-						claims = append(claims, claim)
-					} else {
-						log.Printf("%v: %v", claimReview.Publisher.Name, creationErr.Error())
-					}
-				} else {
-					log.Printf("%v: %v", claimReview.Publisher.Name, err.Error())
-				}
-			}
+		logFailures(publisher, result, err)
+	}
+	return result.Claims
+}
+
+// FetchClaims returns the claims that could be parsed for the given publisher, along with the items which could not.
+// The claims retrieved before an API failure are returned along with the error.
+func (googleSource *GoogleSource) FetchClaims(ctx context.Context, publisher string) (FetchResult, error) {
+	claimDtos, apiErr := googleSource.getClaimDtos(ctx, publisher)
+	result := FetchResult{Fetched: len(claimDtos), Claims: make([]Claim, 0)}
+	for _, claimDto := range claimDtos {
+		if len(claimDto.ClaimReview) == 0 {
+			result.Failures = append(result.Failures, ItemFailure{claimDto.Text, errNoClaimReview})
+			continue
+		}
+		reviews := googleSource.classifyReviews(claimDto.ClaimReview)
+		if len(reviews) == 0 {
+			result.Failures = append(result.Failures, ItemFailure{claimDto.Text, unclassifiedReviewsError(claimDto.ClaimReview)})
+			continue
 		}
+		primaryReview := primaryReview(publisher, reviews)
+		claim, creationErr := NewClaim(claimDto.Text, primaryReview.PublisherName, primaryReview.URL, KindFactCheck, primaryReview.Verdict, primaryReview.ReviewedAt)
+		if creationErr != nil {
+			result.Failures = append(result.Failures, ItemFailure{claimDto.Text, creationErr})
+			continue
+		}
+		claim.Claimant = truncate(claimDto.Claimant, MaxClaimantLength)
+		claim.ClaimDate = claimDto.ClaimDate
+		claim.Reviews = reviews
+		result.Claims = append(result.Claims, claim)
 	}
-	return claims
+	if apiErr != nil {
+		return result, &FetchError{publisher, apiErr}
+	}
+	return result, nil
+}
+
+var errNoClaimReview = errors.New("Claim has no claim review")
+
+// unclassifiedReviewsError lists the textual ratings of claim reviews which could not be classified
+func unclassifiedReviewsError(claimReviews []claimReviewDto) error {
+	textualRatings := make([]string, 0, len(claimReviews))
+	for _, claimReview := range claimReviews {
+		textualRatings = append(textualRatings, "'"+claimReview.TextualRating+"'")
+	}
+	return fmt.Errorf("Could not classify any textual rating of the claim's reviews: %v", strings.Join(textualRatings, ", "))
 }
 
-const matchTrueRegex string = `(?i)^([^n]|n[^o]|no[^t])*(true|real)`
-const trueRating bool = true
-const matchFalseRegex string = `(?i)false|fake|not.(true|real)`
-const falseRating bool = false
+// classifyReviews maps claim review dtos to Reviews.
+// Reviews whose ratings could not be classified are reported by the classifier, so they are skipped silently.
+func (googleSource *GoogleSource) classifyReviews(claimReviews []claimReviewDto) []Review {
+	reviews := make([]Review, 0, len(claimReviews))
+	for _, claimReview := range claimReviews {
+		verdict, err := googleSource.classifier.Classify(claimReview.Publisher.Site, claimReview.LanguageCode, claimReview.TextualRating)
+		if err == nil {
+			reviews = append(reviews, Review{
+				PublisherName: claimReview.Publisher.Name,
+				PublisherSite: claimReview.Publisher.Site,
+				URL:           claimReview.URL,
+				Title:         claimReview.Title,
+				TextualRating: claimReview.TextualRating,
+				Verdict:       verdict,
+				LanguageCode:  claimReview.LanguageCode,
+				ReviewedAt:    claimReview.ReviewDate,
+			}.truncated())
+		}
+	}
+	return reviews
+}
 
-func parseTextualRating(textualRating string) (bool, error) {
-	matchesTrue, matchTrueRegexErr := regexp.MatchString(matchTrueRegex, textualRating)
-	if matchTrueRegexErr != nil {
-		return false, matchTrueRegexErr
+// primaryReview returns the review written by the queried publisher, or the first review if the publisher did not review the claim
+func primaryReview(publisher string, reviews []Review) Review {
+	for _, review := range reviews {
+		if normalizeSite(review.PublisherSite) == normalizeSite(publisher) {
+			return review
+		}
 	}
-	if matchesTrue {
-		return trueRating, nil
+	return reviews[0]
+}
+
+// queryOf returns the query settings of the publisher with their defaults, or DefaultGoogleQuery if the publisher has no settings.
+// A publisher with settings but without a LanguageCode is queried in every language
+func (googleSource *GoogleSource) queryOf(publisher string) GoogleQuery {
+	query, hasSettings := googleSource.queries[publisher]
+	if !hasSettings {
+		return DefaultGoogleQuery()
 	}
-	matchesFalse, matchFalseRegexErr := regexp.MatchString(matchFalseRegex, textualRating)
-	if matchFalseRegexErr != nil {
-		return false, matchFalseRegexErr
+	return query.withDefaults()
+}
+
+// getClaimDtos follows the page tokens returned by the API until there are no pages left, or the publisher's MaxPages or MaxClaims is reached.
+// If an error is encountered, the claims retrieved from previous pages are returned along with the error.
+func (googleSource *GoogleSource) getClaimDtos(ctx context.Context, publisher string) ([]claimDto, error) {
+	query := googleSource.queryOf(publisher)
+	claimDtos := make([]claimDto, 0)
+	pageToken := ""
+	for page := 0; page < query.MaxPages && len(claimDtos) < query.MaxClaims; page++ {
+		claimResponse, apiErr := googleSource.api.getClaims(ctx, publisher, query, pageToken)
+		if apiErr != nil {
+			return claimDtos, apiErr
+		}
+		claimDtos = append(claimDtos, claimResponse.Claims...)
+		if claimResponse.NextPageToken == "" {
+			break
+		}
+		pageToken = claimResponse.NextPageToken
 	}
-	if matchesFalse {
-		return falseRating, nil
+	if len(claimDtos) > query.MaxClaims {
+		claimDtos = claimDtos[:query.MaxClaims]
 	}
-	return false, fmt.Errorf("Could not find any match for textual rating %s", textualRating)
+	return claimDtos, nil
 }
 
 // claimAPI allows us to retrieve to retrieve claims based on an http endpoint
 type claimAPI interface {
-	getClaims(publisher string) (claimResponseDto, error)
+	// getClaims returns the page of claims identified by pageToken, or the first page if pageToken is empty
+	getClaims(ctx context.Context, publisher string, query GoogleQuery, pageToken string) (claimResponseDto, error)
 }
 
-const apiURL string = "https://factchecktools.googleapis.com/v1alpha1/claims:search?languageCode=en-US&pageSize=100&maxAgeDays=20"
+const apiURL string = "https://factchecktools.googleapis.com/v1alpha1/claims:search"
+
+// the maximum duration of a single request to the claims API
+const requestTimeout = 30 * time.Second
+
+// the maximum number of bytes read from the body of a failed response
+const maxErrorMessageBytes = 2048
 
 var claimResponseOnError claimResponseDto = claimResponseDto{}
 
+// retryPolicy determines how requests to the claims API which failed with a transient error are retried
+type retryPolicy struct {
+	// the maximum number of attempts per request, including the first one
+	maxAttempts int
+	// the delay before the first retry, doubled on every subsequent retry
+	baseDelay time.Duration
+	// the maximum delay between two attempts. Requests which the API asks to retry later than this are not retried
+	maxDelay time.Duration
+}
+
+var defaultRetryPolicy = retryPolicy{maxAttempts: 5, baseDelay: time.Second, maxDelay: time.Minute}
+
+// delay returns the time to wait before the given retry (starting at 1). The delay requested by the API through retryAfter is used if set,
+// otherwise the delay grows exponentially with a random jitter so that concurrent collections do not retry in lockstep.
+func (policy retryPolicy) delay(retry int, retryAfter time.Duration, random func() float64) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	backoff := policy.maxDelay
+	if retry < 32 && policy.baseDelay<<uint(retry-1) < policy.maxDelay {
+		backoff = policy.baseDelay << uint(retry-1)
+	}
+	// wait between half and all of the backoff
+	return backoff/2 + time.Duration(random()*float64(backoff/2))
+}
+
 // googleClaimAPI is the real implementation of the claimAPI
 type googleClaimAPI struct {
-	apiKey     string
-	httpClient http.Client
+	apiKey      string
+	apiURL      string
+	httpClient  http.Client
+	retryPolicy retryPolicy
+	// waits for a duration or until the context is done, replaced in tests to avoid waiting
+	sleep func(context.Context, time.Duration) error
+	// returns a random number in [0.0,1.0), used to add jitter to retry delays
+	random func() float64
 }
 
 func newClaimAPI(apiKey string) claimAPI {
-	return &googleClaimAPI{apiKey: apiKey, httpClient: http.Client{}}
+	return &googleClaimAPI{
+		apiKey:      apiKey,
+		apiURL:      apiURL,
+		httpClient:  http.Client{Timeout: requestTimeout},
+		retryPolicy: defaultRetryPolicy,
+		sleep:       sleepContext,
+		random:      rand.Float64,
+	}
+}
+
+// GetClaims returns a slice of ClaimResponseDto for a given news publisher as per the google claim API.
+// Requests failing with a transient error are retried according to the API's retry policy.
+func (api *googleClaimAPI) getClaims(ctx context.Context, publisher string, query GoogleQuery, pageToken string) (claimResponseDto, error) {
+	requestURL := api.requestURL(publisher, query, pageToken)
+	for attempt := 1; ; attempt++ {
+		claimResponse, err := api.requestClaims(ctx, requestURL)
+		if err == nil || attempt >= api.retryPolicy.maxAttempts || !IsTransientError(err) {
+			return claimResponse, err
+		}
+		var retryAfter time.Duration
+		var apiErr apiError
+		if errors.As(err, &apiErr) {
+			retryAfter = apiErr.retryAfter
+		}
+		if retryAfter > api.retryPolicy.maxDelay {
+			return claimResponseOnError, err
+		}
+		delay := api.retryPolicy.delay(attempt, retryAfter, api.random)
+		log.Printf("Retrying claims API request for publisher %v in %v: %v", publisher, delay, err)
+		if sleepErr := api.sleep(ctx, delay); sleepErr != nil {
+			return claimResponseOnError, sleepErr
+		}
+	}
 }
 
-// GetClaims returns a slice of ClaimResponseDto for a given news publisher as per the google claim API
-func (api *googleClaimAPI) getClaims(publisher string) (claimResponseDto, error) {
-	resp, httpError := api.httpClient.Get(apiURL + "&reviewPublisherSiteFilter=" + publisher + "&key=" + api.apiKey)
+// requestClaims sends a single request to the claims API
+func (api *googleClaimAPI) requestClaims(ctx context.Context, requestURL string) (claimResponseDto, error) {
+	request, requestErr := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if requestErr != nil {
+		return claimResponseOnError, requestErr
+	}
+	resp, httpError := api.httpClient.Do(request)
 	if httpError != nil {
-		return claimResponseOnError, httpError
+		if ctx.Err() != nil {
+			return claimResponseOnError, ctx.Err()
+		}
+		return claimResponseOnError, transportError{api.redactAPIKey(httpError)}
 	}
+	defer resp.Body.Close()
 
 	if isFailure(resp) {
-		return claimResponseOnError, fmt.Errorf("Encountered invalid response code while querying claims API: %v", resp.StatusCode)
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorMessageBytes))
+		return claimResponseOnError, apiError{
+			statusCode: resp.StatusCode,
+			message:    strings.TrimSpace(string(message)),
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
-	defer resp.Body.Close()
 	responseBody, parseError := ioutil.ReadAll(resp.Body)
 	if parseError != nil {
-		return claimResponseOnError, parseError
+		if ctx.Err() != nil {
+			return claimResponseOnError, ctx.Err()
+		}
+		return claimResponseOnError, transportError{parseError}
 	}
 	claimResponse := new(claimResponseDto)
 	serializationErr := json.Unmarshal(responseBody, claimResponse)
@@ -114,18 +316,59 @@ func (api *googleClaimAPI) getClaims(publisher string) (claimResponseDto, error)
 	return *claimResponse, nil
 }
 
+// redactAPIKey removes the API key from the request URL included in http client errors so that it is never logged
+func (api *googleClaimAPI) redactAPIKey(err error) error {
+	if urlErr, isURLErr := err.(*url.Error); isURLErr && api.apiKey != "" {
+		urlErr.URL = strings.ReplaceAll(urlErr.URL, url.QueryEscape(api.apiKey), "REDACTED")
+	}
+	return err
+}
+
+// sleepContext waits for the given duration, returning the context's error early if it is done first
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// requestURL builds the URL used to request a page of claims for the given publisher
+func (api *googleClaimAPI) requestURL(publisher string, query GoogleQuery, pageToken string) string {
+	params := url.Values{}
+	params.Set("reviewPublisherSiteFilter", publisher)
+	if query.LanguageCode != "" {
+		params.Set("languageCode", query.LanguageCode)
+	}
+	params.Set("pageSize", strconv.Itoa(query.PageSize))
+	params.Set("maxAgeDays", strconv.Itoa(query.MaxAgeDays))
+	if pageToken != "" {
+		params.Set("pageToken", pageToken)
+	}
+	params.Set("key", api.apiKey)
+	return api.apiURL + "?" + params.Encode()
+}
+
 // DTO returned by the google claim API
 type claimResponseDto struct {
-	Claims []claimDto
+	Claims        []claimDto
+	NextPageToken string
 }
 type claimDto struct {
 	Text        string
+	Claimant    string
+	ClaimDate   time.Time
 	ClaimReview []claimReviewDto
 }
 type claimReviewDto struct {
 	Publisher     publisherDto
 	URL           string
+	Title         string
 	TextualRating string
+	LanguageCode  string
 	ReviewDate    time.Time
 }
 
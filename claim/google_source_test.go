@@ -1,80 +1,16 @@
 package claim
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
 
-func Test_parseTextualRating(t *testing.T) {
-	type args struct {
-		textualRating string
-	}
-	tests := []struct {
-		name          string
-		textualRating string
-		want          bool
-		wantErr       bool
-	}{
-		{
-			name:          "parsing 'true' returns true",
-			textualRating: "true",
-			want:          true,
-			wantErr:       false,
-		},
-		{
-			name:          "parsing 'real' returns true",
-			textualRating: "real",
-			want:          true,
-			wantErr:       false,
-		},
-
-		{
-			name:          "parsing 'false' returns false",
-			textualRating: "false",
-			want:          false,
-			wantErr:       false,
-		},
-		{
-			name:          "parsing 'fake' returns false",
-			textualRating: "fake",
-			want:          false,
-			wantErr:       false,
-		},
-		{
-			name:          "parsing 'not true' returns false",
-			textualRating: "not true",
-			want:          false,
-			wantErr:       false,
-		},
-		{
-			name:          "parsing 'not real' returns false",
-			textualRating: "not real",
-			want:          false,
-			wantErr:       false,
-		},
-		{
-			name:          "parsing without a match returns an error",
-			textualRating: "STRING_THAT_SHOULD_NEVER_MATCH",
-			want:          false,
-			wantErr:       true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseTextualRating(tt.textualRating)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("parseTextualRating() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if got != tt.want {
-				t.Errorf("parseTextualRating() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
 func TestGoogleSource_GetClaims(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -85,19 +21,118 @@ func TestGoogleSource_GetClaims(t *testing.T) {
 			name: "Correctly maps claim dto fields",
 			mockedAPI: mockedClaimAPI{
 				claimDtos: []claimDto{
-					{Text: "article text", ClaimReview: []claimReviewDto{
+					{Text: "article text", Claimant: "claimant", ClaimDate: time.Unix(50, 0), ClaimReview: []claimReviewDto{
 						{
 							Publisher:     publisherDto{Name: "publisher_name", Site: "http://publisher_site.com"},
 							URL:           "http://article_url.com",
+							Title:         "review title",
 							TextualRating: "True",
+							LanguageCode:  "en",
+							ReviewDate:    time.Unix(100, 0),
+						},
+					}},
+				},
+				err: nil,
+			},
+			want: []Claim{
+				withReviews(
+					claim("article text", "publisher_name", "http://article_url.com", KindFactCheck, VerdictTrue, time.Unix(100, 0)),
+					"claimant",
+					time.Unix(50, 0),
+					Review{
+						PublisherName: "publisher_name",
+						PublisherSite: "http://publisher_site.com",
+						URL:           "http://article_url.com",
+						Title:         "review title",
+						TextualRating: "True",
+						Verdict:       VerdictTrue,
+						LanguageCode:  "en",
+						ReviewedAt:    time.Unix(100, 0),
+					},
+				),
+			},
+		},
+		{
+			name: "Keeps every classified review and bases the claim on the queried publisher's review",
+			mockedAPI: mockedClaimAPI{
+				claimDtos: []claimDto{
+					{Text: "article text", ClaimReview: []claimReviewDto{
+						{
+							Publisher:     publisherDto{Name: "other_publisher", Site: "other.com"},
+							URL:           "http://other.com/review",
+							TextualRating: "Mostly true",
 							ReviewDate:    time.Unix(100, 0),
 						},
+						{
+							Publisher:     publisherDto{Name: "unclassified_publisher", Site: "unclassified.com"},
+							URL:           "http://unclassified.com/review",
+							TextualRating: "STRING_THAT_SHOULD_NEVER_MATCH_TEXTUAL_RATING",
+							ReviewDate:    time.Unix(150, 0),
+						},
+						{
+							Publisher:     publisherDto{Name: "any_publisher", Site: "www.anypublisher.com"},
+							URL:           "http://anypublisher.com/review",
+							TextualRating: "Pants on Fire",
+							ReviewDate:    time.Unix(200, 0),
+						},
 					}},
 				},
 				err: nil,
 			},
 			want: []Claim{
-				claim("article text", "publisher_name", "http://article_url.com", true, time.Unix(100, 0)),
+				withReviews(
+					claim("article text", "any_publisher", "http://anypublisher.com/review", KindFactCheck, VerdictFalse, time.Unix(200, 0)),
+					"",
+					time.Time{},
+					Review{
+						PublisherName: "other_publisher",
+						PublisherSite: "other.com",
+						URL:           "http://other.com/review",
+						TextualRating: "Mostly true",
+						Verdict:       VerdictMostlyTrue,
+						ReviewedAt:    time.Unix(100, 0),
+					},
+					Review{
+						PublisherName: "any_publisher",
+						PublisherSite: "www.anypublisher.com",
+						URL:           "http://anypublisher.com/review",
+						TextualRating: "Pants on Fire",
+						Verdict:       VerdictFalse,
+						ReviewedAt:    time.Unix(200, 0),
+					},
+				),
+			},
+		},
+		{
+			name: "Truncates the claimant and review fields to their maximum length",
+			mockedAPI: mockedClaimAPI{
+				claimDtos: []claimDto{
+					{Text: "article text", Claimant: strings.Repeat("c", MaxClaimantLength+1), ClaimReview: []claimReviewDto{
+						{
+							Publisher:     publisherDto{Name: strings.Repeat("p", MaxPublisherNameLength+1), Site: "anypublisher.com"},
+							URL:           "http://anypublisher.com/review",
+							Title:         strings.Repeat("t", MaxReviewTitleLength+1),
+							TextualRating: "True",
+							ReviewDate:    time.Unix(100, 0),
+						},
+					}},
+				},
+			},
+			want: []Claim{
+				withReviews(
+					claim("article text", strings.Repeat("p", MaxPublisherNameLength), "http://anypublisher.com/review", KindFactCheck, VerdictTrue, time.Unix(100, 0)),
+					strings.Repeat("c", MaxClaimantLength),
+					time.Time{},
+					Review{
+						PublisherName: strings.Repeat("p", MaxPublisherNameLength),
+						PublisherSite: "anypublisher.com",
+						URL:           "http://anypublisher.com/review",
+						Title:         strings.Repeat("t", MaxReviewTitleLength),
+						TextualRating: "True",
+						Verdict:       VerdictTrue,
+						ReviewedAt:    time.Unix(100, 0),
+					},
+				),
 			},
 		},
 		{
@@ -106,7 +141,7 @@ func TestGoogleSource_GetClaims(t *testing.T) {
 				claimDtos: []claimDto{{Text: "Do not include this claim"}},
 				err:       errors.New("Encountered error with claim API"),
 			},
-			want:      []Claim{},
+			want: []Claim{},
 		},
 		{
 			name: "Excludes claim dtos without any claim reviews",
@@ -114,7 +149,7 @@ func TestGoogleSource_GetClaims(t *testing.T) {
 				claimDtos: []claimDto{{Text: "Do not include this claim", ClaimReview: []claimReviewDto{}}},
 				err:       nil,
 			},
-			want:      []Claim{},
+			want: []Claim{},
 		},
 		{
 			name: "Excludes claim dtos with invalid textual rating",
@@ -128,13 +163,13 @@ func TestGoogleSource_GetClaims(t *testing.T) {
 				},
 				err: nil,
 			},
-			want:      []Claim{},
+			want: []Claim{},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			googleSource := GoogleSource{api: tt.mockedAPI}
-			if got := googleSource.GetClaims("anypublisher.com"); !reflect.DeepEqual(got, tt.want) {
+			googleSource := GoogleSource{api: tt.mockedAPI, classifier: defaultClassifier()}
+			if got := googleSource.GetClaims(context.Background(), "anypublisher.com"); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("GoogleSource.GetClaims() = %v, want %v", got, tt.want)
 			}
 		})
@@ -146,16 +181,334 @@ type mockedClaimAPI struct {
 	claimDtos []claimDto
 	// the error returned by the mock on request
 	err error
+	// if not nil, the pages returned by the mock by page token. The first page has an empty token
+	pages map[string]claimResponseDto
 }
 
-func (mock mockedClaimAPI) getClaims(publisher string) (claimResponseDto, error) {
-	return claimResponseDto{mock.claimDtos}, mock.err
+func (mock mockedClaimAPI) getClaims(ctx context.Context, publisher string, query GoogleQuery, pageToken string) (claimResponseDto, error) {
+	if mock.pages != nil {
+		page, found := mock.pages[pageToken]
+		if !found {
+			return claimResponseDto{}, errors.New("Unknown page token " + pageToken)
+		}
+		return page, nil
+	}
+	return claimResponseDto{Claims: mock.claimDtos}, mock.err
 }
 
-func claim(title string, publisherName string, url string, isFact bool, reviewedAt time.Time) Claim {
-	c, err := NewClaim(title, publisherName, url, isFact, reviewedAt)
+func TestGoogleSource_GetClaims_Pagination(t *testing.T) {
+	pages := map[string]claimResponseDto{
+		"":       {Claims: []claimDto{trueClaimDto("1"), trueClaimDto("2")}, NextPageToken: "second"},
+		"second": {Claims: []claimDto{trueClaimDto("3"), trueClaimDto("4")}, NextPageToken: "third"},
+		"third":  {Claims: []claimDto{trueClaimDto("5")}},
+	}
+	tests := []struct {
+		name      string
+		query     GoogleQuery
+		mockedAPI mockedClaimAPI
+		wantURLs  []string
+	}{
+		{
+			name:      "Follows page tokens until the last page",
+			query:     GoogleQuery{},
+			mockedAPI: mockedClaimAPI{pages: pages},
+			wantURLs:  []string{"http://1.com", "http://2.com", "http://3.com", "http://4.com", "http://5.com"},
+		},
+		{
+			name:      "Stops after MaxPages pages",
+			query:     GoogleQuery{MaxPages: 2},
+			mockedAPI: mockedClaimAPI{pages: pages},
+			wantURLs:  []string{"http://1.com", "http://2.com", "http://3.com", "http://4.com"},
+		},
+		{
+			name:      "Stops after MaxClaims claims",
+			query:     GoogleQuery{MaxClaims: 3},
+			mockedAPI: mockedClaimAPI{pages: pages},
+			wantURLs:  []string{"http://1.com", "http://2.com", "http://3.com"},
+		},
+		{
+			name:  "Keeps claims of previous pages if a page fails",
+			query: GoogleQuery{},
+			mockedAPI: mockedClaimAPI{pages: map[string]claimResponseDto{
+				"": {Claims: []claimDto{trueClaimDto("1")}, NextPageToken: "missing"},
+			}},
+			wantURLs: []string{"http://1.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			googleSource := GoogleSource{
+				api:        tt.mockedAPI,
+				classifier: defaultClassifier(),
+				queries:    map[string]GoogleQuery{"publisher.com": tt.query},
+			}
+			gotURLs := []string{}
+			for _, c := range googleSource.GetClaims(context.Background(), "publisher.com") {
+				gotURLs = append(gotURLs, c.URL)
+			}
+			if !reflect.DeepEqual(gotURLs, tt.wantURLs) {
+				t.Errorf("GoogleSource.GetClaims() URLs = %v, want %v", gotURLs, tt.wantURLs)
+			}
+		})
+	}
+}
+
+func TestGoogleSource_queryOf(t *testing.T) {
+	googleSource := GoogleSource{queries: map[string]GoogleQuery{
+		"configured.com": {PageSize: 10},
+		"french.com":     {LanguageCode: "fr"},
+	}}
+	defaults := DefaultGoogleQuery()
+	tests := []struct {
+		publisher string
+		want      GoogleQuery
+	}{
+		{publisher: "unconfigured.com", want: defaults},
+		{publisher: "configured.com", want: GoogleQuery{PageSize: 10, MaxAgeDays: defaults.MaxAgeDays, MaxPages: defaults.MaxPages, MaxClaims: defaults.MaxClaims}},
+		{publisher: "french.com", want: GoogleQuery{LanguageCode: "fr", PageSize: defaults.PageSize, MaxAgeDays: defaults.MaxAgeDays, MaxPages: defaults.MaxPages, MaxClaims: defaults.MaxClaims}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.publisher, func(t *testing.T) {
+			if got := googleSource.queryOf(tt.publisher); got != tt.want {
+				t.Errorf("GoogleSource.queryOf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_googleClaimAPI_requestURL(t *testing.T) {
+	api := googleClaimAPI{apiKey: "KEY", apiURL: "http://api.com/claims:search"}
+	tests := []struct {
+		name      string
+		query     GoogleQuery
+		pageToken string
+		want      string
+	}{
+		{
+			name:      "Includes the query settings of the publisher",
+			query:     GoogleQuery{LanguageCode: "en-US", PageSize: 50, MaxAgeDays: 10},
+			pageToken: "",
+			want:      "http://api.com/claims:search?key=KEY&languageCode=en-US&maxAgeDays=10&pageSize=50&reviewPublisherSiteFilter=publisher.com",
+		},
+		{
+			name:      "Omits empty language and includes page token",
+			query:     GoogleQuery{PageSize: 100, MaxAgeDays: 20},
+			pageToken: "TOKEN",
+			want:      "http://api.com/claims:search?key=KEY&maxAgeDays=20&pageSize=100&pageToken=TOKEN&reviewPublisherSiteFilter=publisher.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := api.requestURL("publisher.com", tt.query, tt.pageToken); got != tt.want {
+				t.Errorf("googleClaimAPI.requestURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// returns a claim dto rated true with the url http://<id>.com
+func trueClaimDto(id string) claimDto {
+	return claimDto{Text: "claim " + id, ClaimReview: []claimReviewDto{
+		{
+			Publisher:     publisherDto{Name: "publisher_name", Site: "publisher.com"},
+			URL:           "http://" + id + ".com",
+			TextualRating: "True",
+			ReviewDate:    time.Unix(100, 0),
+		},
+	}}
+}
+
+func claim(title string, publisherName string, url string, kind Kind, verdict Verdict, reviewedAt time.Time) Claim {
+	c, err := NewClaim(title, publisherName, url, kind, verdict, reviewedAt)
 	if err != nil {
 		panic("Claim constructed for test assertion is not valid: " + err.Error())
 	}
 	return c
-}
\ No newline at end of file
+}
+
+// returns the claim with its claimant, claim date and reviews set
+func withReviews(c Claim, claimant string, claimDate time.Time, reviews ...Review) Claim {
+	c.Claimant = claimant
+	c.ClaimDate = claimDate
+	c.Reviews = reviews
+	return c
+}
+
+func defaultClassifier() *RuleBasedClassifier {
+	classifier, err := NewRuleBasedClassifier(DefaultRatingRules())
+	if err != nil {
+		panic("Default rating rules are not valid: " + err.Error())
+	}
+	return classifier
+}
+
+func Test_googleClaimAPI_getClaims_Retries(t *testing.T) {
+	tests := []struct {
+		name string
+		// the status codes returned by the server on each request, the last one is repeated
+		statusCodes   []int
+		retryAfter    string
+		responseBody  string
+		wantRequests  int
+		wantDelays    []time.Duration
+		wantErr       bool
+		wantAuthErr   bool
+		wantTransient bool
+	}{
+		{
+			name:         "Retries server errors with exponential backoff until success",
+			statusCodes:  []int{503, 500, 200},
+			responseBody: `{"claims": []}`,
+			wantRequests: 3,
+			wantDelays:   []time.Duration{time.Second, 2 * time.Second},
+			wantErr:      false,
+		},
+		{
+			name:         "Honors the Retry-After header of rate limited responses",
+			statusCodes:  []int{429, 200},
+			retryAfter:   "7",
+			responseBody: `{"claims": []}`,
+			wantRequests: 2,
+			wantDelays:   []time.Duration{7 * time.Second},
+			wantErr:      false,
+		},
+		{
+			name:          "Does not retry if Retry-After exceeds the maximum delay",
+			statusCodes:   []int{429},
+			retryAfter:    "3600",
+			wantRequests:  1,
+			wantDelays:    []time.Duration{},
+			wantErr:       true,
+			wantTransient: true,
+		},
+		{
+			name:          "Gives up after the maximum number of attempts",
+			statusCodes:   []int{502},
+			wantRequests:  3,
+			wantDelays:    []time.Duration{time.Second, 2 * time.Second},
+			wantErr:       true,
+			wantTransient: true,
+		},
+		{
+			name:         "Does not retry invalid API keys",
+			statusCodes:  []int{400},
+			responseBody: `{"error": {"message": "API key not valid. Please pass a valid API key.", "status": "INVALID_ARGUMENT"}}`,
+			wantRequests: 1,
+			wantDelays:   []time.Duration{},
+			wantErr:      true,
+			wantAuthErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				statusCode := tt.statusCodes[len(tt.statusCodes)-1]
+				if requests < len(tt.statusCodes) {
+					statusCode = tt.statusCodes[requests]
+				}
+				requests++
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(statusCode)
+				w.Write([]byte(tt.responseBody))
+			}))
+			defer server.Close()
+
+			delays := []time.Duration{}
+			api := &googleClaimAPI{
+				apiKey:      "KEY",
+				apiURL:      server.URL,
+				retryPolicy: retryPolicy{maxAttempts: 3, baseDelay: 2 * time.Second, maxDelay: time.Minute},
+				sleep: func(ctx context.Context, delay time.Duration) error {
+					delays = append(delays, delay)
+					return nil
+				},
+				// always picks the smallest delay, i.e. half of the backoff
+				random: func() float64 { return 0 },
+			}
+			_, err := api.getClaims(context.Background(), "publisher.com", DefaultGoogleQuery(), "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("googleClaimAPI.getClaims() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if IsAuthError(err) != tt.wantAuthErr {
+				t.Errorf("IsAuthError(%v) = %v, want %v", err, IsAuthError(err), tt.wantAuthErr)
+			}
+			if IsTransientError(err) != tt.wantTransient {
+				t.Errorf("IsTransientError(%v) = %v, want %v", err, IsTransientError(err), tt.wantTransient)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("Requests sent = %v, want %v", requests, tt.wantRequests)
+			}
+			if !reflect.DeepEqual(delays, tt.wantDelays) {
+				t.Errorf("Retry delays = %v, want %v", delays, tt.wantDelays)
+			}
+		})
+	}
+}
+
+func Test_googleClaimAPI_getClaims_Cancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	api := &googleClaimAPI{
+		apiKey:      "KEY",
+		apiURL:      server.URL,
+		retryPolicy: defaultRetryPolicy,
+		sleep: func(ctx context.Context, delay time.Duration) error {
+			cancel()
+			return sleepContext(ctx, delay)
+		},
+		random: func() float64 { return 0 },
+	}
+	if _, err := api.getClaims(ctx, "publisher.com", DefaultGoogleQuery(), ""); err != context.Canceled {
+		t.Errorf("googleClaimAPI.getClaims() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestGoogleSource_FetchClaims(t *testing.T) {
+	apiErr := apiError{statusCode: 403}
+	mockedAPI := mockedClaimAPI{pages: map[string]claimResponseDto{
+		"": {Claims: []claimDto{
+			trueClaimDto("1"),
+			{Text: "claim without reviews"},
+			{Text: "claim with unclassified rating", ClaimReview: []claimReviewDto{{TextualRating: "STRING_THAT_SHOULD_NEVER_MATCH_TEXTUAL_RATING"}}},
+		}, NextPageToken: "failing"},
+	}}
+	googleSource := GoogleSource{api: failingPageAPI{mockedAPI, "failing", apiErr}, classifier: defaultClassifier()}
+
+	result, err := googleSource.FetchClaims(context.Background(), "publisher.com")
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) || !IsAuthError(err) {
+		t.Errorf("GoogleSource.FetchClaims() error = %v, want FetchError caused by an auth error", err)
+	}
+	if result.Fetched != 3 || len(result.Claims) != 1 {
+		t.Errorf("GoogleSource.FetchClaims() fetched %v items and %v claims, want 3 items and 1 claim", result.Fetched, len(result.Claims))
+	}
+	wantFailedItems := []string{"claim without reviews", "claim with unclassified rating"}
+	gotFailedItems := []string{}
+	for _, failure := range result.Failures {
+		gotFailedItems = append(gotFailedItems, failure.Item)
+	}
+	if !reflect.DeepEqual(gotFailedItems, wantFailedItems) {
+		t.Errorf("GoogleSource.FetchClaims() failed items = %v, want %v", gotFailedItems, wantFailedItems)
+	}
+}
+
+// failingPageAPI returns an error when the page with the given token is requested
+type failingPageAPI struct {
+	mockedClaimAPI
+	failingPageToken string
+	err              error
+}
+
+func (mock failingPageAPI) getClaims(ctx context.Context, publisher string, query GoogleQuery, pageToken string) (claimResponseDto, error) {
+	if pageToken == mock.failingPageToken {
+		return claimResponseDto{}, mock.err
+	}
+	return mock.mockedClaimAPI.getClaims(ctx, publisher, query, pageToken)
+}
@@ -0,0 +1,107 @@
+package claim
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathSegment is a step of a JSONPath expression, selecting a member, an array element or every child of a value
+type jsonPathSegment struct {
+	field    string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath parses a minimal JSONPath expression made of ".member", "['member']", "[index]" and "[*]" or ".*" wildcards.
+// The leading "$" is optional, and a path may start with a member name, e.g. "review.rating".
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	rest := strings.TrimPrefix(path, "$")
+	segments := make([]jsonPathSegment, 0)
+	for rest != "" {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("Invalid JSONPath '%v': missing ']'", path)
+			}
+			content := rest[1:end]
+			rest = rest[end+1:]
+			if content == "*" {
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			} else if len(content) >= 2 && (content[0] == '\'' || content[0] == '"') && content[len(content)-1] == content[0] {
+				segments = append(segments, jsonPathSegment{field: content[1 : len(content)-1]})
+			} else if index, err := strconv.Atoi(content); err == nil && index >= 0 {
+				segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+			} else {
+				return nil, fmt.Errorf("Invalid JSONPath '%v': invalid subscript '%v'", path, content)
+			}
+		default:
+			// the dot is optional before the first member of relative paths
+			if rest[0] == '.' {
+				rest = rest[1:]
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			field := rest[:end]
+			rest = rest[end:]
+			if field == "" {
+				return nil, fmt.Errorf("Invalid JSONPath '%v': empty member name", path)
+			}
+			segments = append(segments, jsonPathSegment{field: field, wildcard: field == "*"})
+		}
+	}
+	return segments, nil
+}
+
+// evaluateJSONPath returns the values matching the segments within a value decoded by encoding/json
+func evaluateJSONPath(value interface{}, segments []jsonPathSegment) []interface{} {
+	matches := []interface{}{value}
+	for _, segment := range segments {
+		next := make([]interface{}, 0, len(matches))
+		for _, match := range matches {
+			switch typed := match.(type) {
+			case map[string]interface{}:
+				if segment.wildcard {
+					// members are visited in a stable order since maps are not ordered
+					keys := make([]string, 0, len(typed))
+					for key := range typed {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, typed[key])
+					}
+				} else if child, found := typed[segment.field]; found && !segment.isIndex {
+					next = append(next, child)
+				}
+			case []interface{}:
+				if segment.wildcard {
+					next = append(next, typed...)
+				} else if segment.isIndex && segment.index < len(typed) {
+					next = append(next, typed[segment.index])
+				}
+			}
+		}
+		matches = next
+	}
+	return matches
+}
+
+// jsonString formats a scalar JSON value as a string, returning an empty string for other values
+func jsonString(value interface{}) string {
+	switch typed := value.(type) {
+	case string:
+		return strings.TrimSpace(typed)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(typed)
+	default:
+		return ""
+	}
+}
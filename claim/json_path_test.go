@@ -0,0 +1,47 @@
+package claim
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_evaluateJSONPath(t *testing.T) {
+	var document interface{}
+	json.Unmarshal([]byte(`{"items": [{"title": "a", "score": 1.5}, {"title": "b", "tags": {"x": true, "y": "z"}}], "name": "list"}`), &document)
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{"Root member", "$.name", []string{"list"}, false},
+		{"Relative member", "name", []string{"list"}, false},
+		{"Array index", "$.items[1].title", []string{"b"}, false},
+		{"Array wildcard", "$.items[*].title", []string{"a", "b"}, false},
+		{"Bracket member", "$['items'][0].score", []string{"1.5"}, false},
+		{"Object wildcard in key order", "items[1].tags.*", []string{"true", "z"}, false},
+		{"Missing member", "$.items[0].missing", []string{}, false},
+		{"Index out of range", "$.items[5]", []string{}, false},
+		{"Unclosed subscript", "$.items[0", nil, true},
+		{"Empty member", "$..name", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments, err := parseJSONPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseJSONPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := []string{}
+			for _, value := range evaluateJSONPath(document, segments) {
+				got = append(got, jsonString(value))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("evaluateJSONPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
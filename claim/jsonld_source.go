@@ -0,0 +1,382 @@
+package claim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// JSONLDConfig describes how the articles of a fact-checking site embedding schema.org ClaimReview JSON-LD are found
+type JSONLDConfig struct {
+	// selects the links to articles when the configured URL is an HTML page listing articles, e.g. "article a@href".
+	// The URL is read as a sitemap if empty
+	ArticleLinks string
+	// a regex which article URLs must match, e.g. "/fact-check/". Every article is crawled if empty
+	ArticlePattern string
+	// the maximum number of articles crawled per run, the most recently modified ones first. Defaults to 50
+	MaxArticles int
+	// the publisher of the site. The author of each claim review is used as its publisher if the publisher has no name
+	Publisher Publisher
+}
+
+const defaultMaxArticles = 50
+
+func (config JSONLDConfig) maxArticles() int {
+	if config.MaxArticles <= 0 {
+		return defaultMaxArticles
+	}
+	return config.MaxArticles
+}
+
+// JSONLDSource is a claim source crawling the articles of fact-checking sites and extracting the schema.org ClaimReview
+// JSON-LD blocks they embed, which hold the same data as the google fact-check API
+type JSONLDSource struct {
+	// maps the textual ratings of claim reviews to verdicts
+	classifier RatingClassifier
+	// the configuration of each sitemap or article list, keyed by URL
+	configs    map[string]JSONLDConfig
+	httpClient http.Client
+	// pages larger than maxPageBytes are rejected
+	maxPageBytes int64
+}
+
+// NewJSONLDSource creates a claim source crawling the sitemaps or article lists configured in configs, keyed by URL
+func NewJSONLDSource(classifier RatingClassifier, configs map[string]JSONLDConfig) *JSONLDSource {
+	return &JSONLDSource{
+		classifier:   classifier,
+		configs:      configs,
+		httpClient:   http.Client{Timeout: pageRequestTimeout},
+		maxPageBytes: defaultMaxPageBytes,
+	}
+}
+
+// GetClaims returns claims that could be parsed from the articles listed at listURL
+func (jsonldSource *JSONLDSource) GetClaims(ctx context.Context, listURL string) []Claim {
+	result, err := jsonldSource.FetchClaims(ctx, listURL)
+	logFailures(listURL, result, err)
+	return result.Claims
+}
+
+// FetchClaims crawls the articles listed by the sitemap or article list at listURL and returns the claims that could be parsed
+// from their ClaimReview blocks, along with the claim reviews and articles which could not.
+func (jsonldSource *JSONLDSource) FetchClaims(ctx context.Context, listURL string) (FetchResult, error) {
+	result := FetchResult{Claims: make([]Claim, 0)}
+	config, found := jsonldSource.configs[listURL]
+	if !found {
+		return result, &FetchError{listURL, errNoJSONLDConfig}
+	}
+	articleURLs, listErr := jsonldSource.articleURLs(ctx, listURL, config)
+	if listErr != nil {
+		return result, &FetchError{listURL, listErr}
+	}
+
+	for _, articleURL := range articleURLs {
+		if ctx.Err() != nil {
+			return result, &FetchError{listURL, ctx.Err()}
+		}
+		page, pageErr := fetchPage(ctx, &jsonldSource.httpClient, articleURL, jsonldSource.maxPageBytes)
+		if pageErr != nil {
+			result.Fetched++
+			result.Failures = append(result.Failures, ItemFailure{articleURL, pageErr})
+			continue
+		}
+		claimReviews, extractErr := extractClaimReviews(page)
+		if extractErr != nil {
+			result.Fetched++
+			result.Failures = append(result.Failures, ItemFailure{articleURL, extractErr})
+			continue
+		}
+		result.Fetched += len(claimReviews)
+		for _, claimReview := range claimReviews {
+			claim, claimErr := jsonldSource.asClaim(articleURL, config, claimReview)
+			if claimErr == nil {
+				result.Claims = append(result.Claims, claim)
+			} else {
+				result.Failures = append(result.Failures, ItemFailure{claimReview.id(articleURL), claimErr})
+			}
+		}
+	}
+	return result, nil
+}
+
+var errNoJSONLDConfig = errors.New("No JSON-LD configuration for sitemap or article list")
+
+// articleURLs returns the URLs of the articles to crawl, read from a sitemap or from an HTML article list
+func (jsonldSource *JSONLDSource) articleURLs(ctx context.Context, listURL string, config JSONLDConfig) ([]string, error) {
+	var pattern *regexp.Regexp
+	if config.ArticlePattern != "" {
+		var patternErr error
+		if pattern, patternErr = regexp.Compile(config.ArticlePattern); patternErr != nil {
+			return nil, fmt.Errorf("Invalid article pattern: %v", patternErr)
+		}
+	}
+	page, pageErr := fetchPage(ctx, &jsonldSource.httpClient, listURL, jsonldSource.maxPageBytes)
+	if pageErr != nil {
+		return nil, pageErr
+	}
+
+	var entries []sitemapEntryDto
+	if config.ArticleLinks == "" {
+		var sitemapErr error
+		if entries, sitemapErr = jsonldSource.sitemapEntries(ctx, page); sitemapErr != nil {
+			return nil, sitemapErr
+		}
+	} else {
+		document, parseErr := goquery.NewDocumentFromReader(bytes.NewReader(page))
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		for _, link := range htmlFields(document.Selection, config.ArticleLinks) {
+			entries = append(entries, sitemapEntryDto{Loc: link})
+		}
+	}
+
+	base, baseErr := url.Parse(listURL)
+	if baseErr != nil {
+		return nil, baseErr
+	}
+	// the most recently modified articles come first, articles without a modification date keep their order at the end
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].LastMod > entries[j].LastMod })
+	articleURLs := make([]string, 0)
+	for _, entry := range entries {
+		articleURL, urlErr := base.Parse(strings.TrimSpace(entry.Loc))
+		if urlErr != nil || (pattern != nil && !pattern.MatchString(articleURL.String())) || contains(articleURLs, articleURL.String()) {
+			continue
+		}
+		articleURLs = append(articleURLs, articleURL.String())
+		if len(articleURLs) == config.maxArticles() {
+			break
+		}
+	}
+	return articleURLs, nil
+}
+
+// sitemapEntries returns the entries of a sitemap, following the sitemaps listed by a sitemap index
+func (jsonldSource *JSONLDSource) sitemapEntries(ctx context.Context, page []byte) ([]sitemapEntryDto, error) {
+	var sitemap sitemapDto
+	if err := xml.Unmarshal(page, &sitemap); err != nil {
+		return nil, fmt.Errorf("Invalid sitemap: %v", err)
+	}
+	if sitemap.XMLName.Local != "urlset" && sitemap.XMLName.Local != "sitemapindex" {
+		return nil, fmt.Errorf("Invalid sitemap: unexpected root element '%v'", sitemap.XMLName.Local)
+	}
+	entries := sitemap.URLs
+	// nested sitemaps are not followed further to bound the number of requests
+	for _, nestedSitemap := range sitemap.Sitemaps {
+		nestedPage, pageErr := fetchPage(ctx, &jsonldSource.httpClient, strings.TrimSpace(nestedSitemap.Loc), jsonldSource.maxPageBytes)
+		if pageErr != nil {
+			return nil, pageErr
+		}
+		var nested sitemapDto
+		if err := xml.Unmarshal(nestedPage, &nested); err != nil {
+			return nil, fmt.Errorf("Invalid sitemap %v: %v", nestedSitemap.Loc, err)
+		}
+		entries = append(entries, nested.URLs...)
+	}
+	return entries, nil
+}
+
+// asClaim converts a ClaimReview found in an article to a claim whose single review is the ClaimReview itself
+func (jsonldSource *JSONLDSource) asClaim(articleURL string, config JSONLDConfig, claimReview claimReviewLD) (Claim, error) {
+	reviewURL := claimReview.url(articleURL)
+	reviewedAt, dateErr := parseLDDate(ldString(claimReview["datePublished"]))
+	if dateErr != nil {
+		return Claim{}, fmt.Errorf("Invalid datePublished: %v", dateErr)
+	}
+	author := ldFirstObject(claimReview["author"])
+	publisherName, publisherSite := ldString(author["name"]), ldString(author["url"])
+	if publisherSite == "" {
+		if parsedURL, err := url.Parse(reviewURL); err == nil {
+			publisherSite = parsedURL.Host
+		}
+	}
+	if config.Publisher.Name != "" {
+		publisherName = config.Publisher.Name
+	}
+	rating := ldFirstObject(claimReview["reviewRating"])
+	textualRating := ldString(rating["alternateName"])
+	if textualRating == "" {
+		textualRating = ldString(rating["name"])
+	}
+	languageCode := ldString(claimReview["inLanguage"])
+	verdict, classifyErr := jsonldSource.classifier.Classify(publisherSite, languageCode, textualRating)
+	if classifyErr != nil {
+		return Claim{}, classifyErr
+	}
+
+	claim, creationErr := NewClaim(ldString(claimReview["claimReviewed"]), truncatePublisherName(publisherName), reviewURL, KindFactCheck, verdict, reviewedAt)
+	if creationErr != nil {
+		return Claim{}, creationErr
+	}
+	itemReviewed := ldFirstObject(claimReview["itemReviewed"])
+	claim.Claimant = truncate(ldString(ldFirstObject(itemReviewed["author"])["name"]), MaxClaimantLength)
+	if claimDate, err := parseLDDate(ldString(itemReviewed["datePublished"])); err == nil {
+		claim.ClaimDate = claimDate
+	}
+	for _, appearance := range ldValues(itemReviewed["appearance"]) {
+		if appearanceURL := ldURL(appearance); appearanceURL != "" {
+			claim.AppearanceURL = appearanceURL
+			break
+		}
+	}
+	claim.Reviews = []Review{Review{
+		PublisherName: publisherName,
+		PublisherSite: publisherSite,
+		URL:           reviewURL,
+		Title:         ldString(claimReview["headline"]),
+		TextualRating: textualRating,
+		Verdict:       verdict,
+		LanguageCode:  languageCode,
+		ReviewedAt:    reviewedAt,
+	}.truncated()}
+	return claim, nil
+}
+
+// claimReviewLD is a schema.org ClaimReview node decoded from JSON-LD
+type claimReviewLD map[string]interface{}
+
+// url returns the URL of the claim review, or the URL of the article embedding it if it has none
+func (claimReview claimReviewLD) url(articleURL string) string {
+	if reviewURL := ldURL(claimReview["url"]); reviewURL != "" {
+		return reviewURL
+	}
+	return articleURL
+}
+
+// id identifies a claim review by the claim it reviews, or by its URL if the claim is missing
+func (claimReview claimReviewLD) id(articleURL string) string {
+	if claimReviewed := ldString(claimReview["claimReviewed"]); claimReviewed != "" {
+		return claimReviewed
+	}
+	return claimReview.url(articleURL)
+}
+
+// extractClaimReviews returns the ClaimReview nodes of the JSON-LD blocks embedded in an HTML page.
+// Blocks which are not valid JSON are skipped, an error is only returned if the page is not valid HTML.
+func extractClaimReviews(page []byte) ([]claimReviewLD, error) {
+	document, parseErr := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	claimReviews := make([]claimReviewLD, 0)
+	document.Find(`script[type="application/ld+json"]`).Each(func(_ int, script *goquery.Selection) {
+		var block interface{}
+		if err := json.Unmarshal([]byte(script.Text()), &block); err != nil {
+			return
+		}
+		for _, node := range ldNodes(block) {
+			if ldHasType(node, "ClaimReview") {
+				claimReviews = append(claimReviews, claimReviewLD(node))
+			}
+		}
+	})
+	return claimReviews, nil
+}
+
+// ldNodes returns the top-level nodes of a JSON-LD block, which can be a single node, an array of nodes or a @graph
+func ldNodes(block interface{}) []map[string]interface{} {
+	nodes := make([]map[string]interface{}, 0)
+	for _, value := range ldValues(block) {
+		node, isNode := value.(map[string]interface{})
+		if !isNode {
+			continue
+		}
+		if graph, hasGraph := node["@graph"]; hasGraph {
+			nodes = append(nodes, ldNodes(graph)...)
+		} else {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// ldHasType returns true if the @type of the node, which can be a string or an array, includes the given type
+func ldHasType(node map[string]interface{}, nodeType string) bool {
+	for _, value := range ldValues(node["@type"]) {
+		if typeName, isString := value.(string); isString && (typeName == nodeType || strings.HasSuffix(typeName, "/"+nodeType)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ldValues returns the elements of a JSON-LD value, which is either an array or a single value
+func ldValues(value interface{}) []interface{} {
+	if value == nil {
+		return nil
+	}
+	if values, isArray := value.([]interface{}); isArray {
+		return values
+	}
+	return []interface{}{value}
+}
+
+// ldFirstObject returns the first node of a JSON-LD value, or an empty node if it holds none
+func ldFirstObject(value interface{}) map[string]interface{} {
+	for _, element := range ldValues(value) {
+		if node, isNode := element.(map[string]interface{}); isNode {
+			return node
+		}
+	}
+	return map[string]interface{}{}
+}
+
+// ldString returns the first string of a JSON-LD value, reading the @value of value objects
+func ldString(value interface{}) string {
+	for _, element := range ldValues(value) {
+		switch typed := element.(type) {
+		case string:
+			return strings.TrimSpace(typed)
+		case map[string]interface{}:
+			if text := ldString(typed["@value"]); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// ldURL returns the URL held by a JSON-LD value, which can be a URL string or a node with a url or @id
+func ldURL(value interface{}) string {
+	if text := ldString(value); text != "" {
+		return text
+	}
+	node := ldFirstObject(value)
+	if nodeURL := ldString(node["url"]); nodeURL != "" {
+		return nodeURL
+	}
+	return ldString(node["@id"])
+}
+
+// parseLDDate parses a schema.org Date or DateTime
+func parseLDDate(date string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if parsed, err := time.Parse(layout, date); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid date '%v'", date)
+}
+
+type sitemapDto struct {
+	// "urlset" for sitemaps, or "sitemapindex" for sitemaps listing other sitemaps
+	XMLName  xml.Name
+	URLs     []sitemapEntryDto `xml:"url"`
+	Sitemaps []sitemapEntryDto `xml:"sitemap"`
+}
+
+type sitemapEntryDto struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
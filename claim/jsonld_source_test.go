@@ -0,0 +1,150 @@
+package claim
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// returns a server serving the JSON-LD testdata files, in which "{{server}}" is replaced with the server's URL
+func jsonldServer(t *testing.T) *httptest.Server {
+	fixtures := map[string]string{
+		"/sitemap.xml":            "jsonld_sitemap_index.xml",
+		"/sitemap-articles.xml":   "jsonld_sitemap.xml",
+		"/list":                   "jsonld_list.html",
+		"/about":                  "jsonld_list.html",
+		"/fact-check/moon-cheese": "jsonld_moon-cheese.html",
+		"/fact-check/water-wet":   "jsonld_water-wet.html",
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fixture, found := fixtures[r.URL.Path]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		content, err := ioutil.ReadFile("testdata/" + fixture)
+		if err != nil {
+			t.Fatalf("Failed to read fixture %v: %v", fixture, err)
+		}
+		w.Write([]byte(strings.ReplaceAll(string(content), "{{server}}", server.URL)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJSONLDSource_FetchClaims(t *testing.T) {
+	server := jsonldServer(t)
+	moonCheese := withReviews(
+		claim("The moon is made of cheese", "Fact Checker", server.URL+"/fact-check/moon-cheese", KindFactCheck, VerdictFalse, time.Date(2020, 8, 6, 10, 0, 0, 0, time.UTC)),
+		"Some Blogger",
+		time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC),
+		Review{
+			PublisherName: "Fact Checker",
+			PublisherSite: "factchecker.example",
+			URL:           server.URL + "/fact-check/moon-cheese",
+			Title:         "No, the moon is not made of cheese",
+			TextualRating: "Pants on Fire",
+			Verdict:       VerdictFalse,
+			LanguageCode:  "en",
+			ReviewedAt:    time.Date(2020, 8, 6, 10, 0, 0, 0, time.UTC),
+		},
+	)
+	moonCheese.AppearanceURL = "http://blog.example/moon"
+	waterWet := withReviews(
+		claim("Water is wet", "Fact Checker", server.URL+"/fact-check/water-wet", KindFactCheck, VerdictMostlyTrue, time.Date(2020, 8, 5, 0, 0, 0, 0, time.UTC)),
+		"",
+		time.Time{},
+		Review{
+			PublisherName: "Fact Checker",
+			PublisherSite: "factchecker.example",
+			URL:           server.URL + "/fact-check/water-wet",
+			TextualRating: "Mostly True",
+			Verdict:       VerdictMostlyTrue,
+			ReviewedAt:    time.Date(2020, 8, 5, 0, 0, 0, 0, time.UTC),
+		},
+	)
+	waterWet.AppearanceURL = "http://forum.example/water"
+
+	tests := []struct {
+		name            string
+		listPath        string
+		config          JSONLDConfig
+		wantClaims      []Claim
+		wantFailedItems []string
+	}{
+		{
+			name:            "Crawls the articles of a sitemap index from the most recently modified",
+			listPath:        "/sitemap.xml",
+			config:          JSONLDConfig{ArticlePattern: "/fact-check/"},
+			wantClaims:      []Claim{moonCheese, waterWet},
+			wantFailedItems: []string{"Ice is cold", server.URL + "/fact-check/missing"},
+		},
+		{
+			name:       "Crawls at most MaxArticles articles",
+			listPath:   "/sitemap.xml",
+			config:     JSONLDConfig{ArticlePattern: "/fact-check/", MaxArticles: 1},
+			wantClaims: []Claim{moonCheese},
+		},
+		{
+			name:            "Crawls the articles linked by an HTML list",
+			listPath:        "/list",
+			config:          JSONLDConfig{ArticleLinks: "article a@href"},
+			wantClaims:      []Claim{moonCheese, waterWet},
+			wantFailedItems: []string{"Ice is cold", server.URL + "/fact-check/missing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listURL := server.URL + tt.listPath
+			jsonldSource := NewJSONLDSource(defaultClassifier(), map[string]JSONLDConfig{listURL: tt.config})
+			result, err := jsonldSource.FetchClaims(context.Background(), listURL)
+			if err != nil {
+				t.Fatalf("JSONLDSource.FetchClaims() error = %v, want nil", err)
+			}
+			if !reflect.DeepEqual(result.Claims, tt.wantClaims) {
+				t.Errorf("JSONLDSource.FetchClaims() claims = %#v, want %#v", result.Claims, tt.wantClaims)
+			}
+			var gotFailedItems []string
+			for _, failure := range result.Failures {
+				gotFailedItems = append(gotFailedItems, failure.Item)
+			}
+			if !reflect.DeepEqual(gotFailedItems, tt.wantFailedItems) {
+				t.Errorf("JSONLDSource.FetchClaims() failed items = %v, want %v", gotFailedItems, tt.wantFailedItems)
+			}
+			if wantFetched := len(tt.wantClaims) + len(tt.wantFailedItems); result.Fetched != wantFetched {
+				t.Errorf("JSONLDSource.FetchClaims() fetched = %v, want %v", result.Fetched, wantFetched)
+			}
+		})
+	}
+}
+
+func TestJSONLDSource_FetchClaims_Errors(t *testing.T) {
+	server := jsonldServer(t)
+	tests := []struct {
+		name     string
+		listPath string
+		configs  map[string]JSONLDConfig
+	}{
+		{"Sitemap without configuration", "/sitemap.xml", map[string]JSONLDConfig{}},
+		{"Missing sitemap", "/missing.xml", map[string]JSONLDConfig{server.URL + "/missing.xml": {}}},
+		{"Invalid sitemap", "/list", map[string]JSONLDConfig{server.URL + "/list": {}}},
+		{"Invalid article pattern", "/sitemap.xml", map[string]JSONLDConfig{server.URL + "/sitemap.xml": {ArticlePattern: "("}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listURL := server.URL + tt.listPath
+			_, err := NewJSONLDSource(defaultClassifier(), tt.configs).FetchClaims(context.Background(), listURL)
+			var fetchErr *FetchError
+			if !errors.As(err, &fetchErr) || fetchErr.Publisher != listURL {
+				t.Errorf("JSONLDSource.FetchClaims() error = %v, want FetchError for %v", err, listURL)
+			}
+		})
+	}
+}
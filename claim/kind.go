@@ -0,0 +1,41 @@
+package claim
+
+import "fmt"
+
+// Kind is the origin of a claim, which tells how its verdict was established
+type Kind string
+
+const (
+	// a claim reviewed by a fact-checking publisher
+	KindFactCheck Kind = "fact-check"
+	// a headline published by a news publisher, considered true
+	KindNews Kind = "news"
+	// a headline published by a satire publisher, considered fake
+	KindSatire Kind = "satire"
+)
+
+// Kinds lists every valid kind
+var Kinds = []Kind{
+	KindFactCheck,
+	KindNews,
+	KindSatire,
+}
+
+// ParseKind returns the Kind matching the given string, or an error if it is not a valid kind
+func ParseKind(s string) (Kind, error) {
+	kind := Kind(s)
+	if !kind.IsValid() {
+		return "", fmt.Errorf("Invalid kind '%v'", s)
+	}
+	return kind, nil
+}
+
+// IsValid returns true if the kind is one of the known Kinds
+func (k Kind) IsValid() bool {
+	for _, kind := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
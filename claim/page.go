@@ -0,0 +1,48 @@
+package claim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// responseTooLargeError is returned when a response body exceeds the maximum size accepted by a source
+type responseTooLargeError struct {
+	maxBytes int64
+}
+
+func (e responseTooLargeError) Error() string {
+	return fmt.Sprintf("Response is larger than %v bytes", e.maxBytes)
+}
+
+// readLimited reads the whole body, returning a responseTooLargeError if it is larger than maxBytes
+func readLimited(body io.Reader, maxBytes int64) ([]byte, error) {
+	// one more byte than allowed is read to tell bodies of the maximum size apart from larger ones
+	content, err := ioutil.ReadAll(io.LimitReader(body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxBytes {
+		return nil, responseTooLargeError{maxBytes}
+	}
+	return content, nil
+}
+
+// fetchPage downloads a page, returning an error if the response has a failure status code or is larger than maxBytes
+func fetchPage(ctx context.Context, httpClient *http.Client, pageURL string, maxBytes int64) ([]byte, error) {
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if reqErr != nil {
+		return nil, reqErr
+	}
+	resp, respErr := httpClient.Do(req)
+	if respErr != nil {
+		return nil, respErr
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("Encountered invalid response code while fetching page: %v", resp.StatusCode)
+	}
+	return readLimited(resp.Body, maxBytes)
+}
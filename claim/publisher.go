@@ -0,0 +1,35 @@
+package claim
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Publisher identifies a publisher of claims, displayed along with its claims
+type Publisher struct {
+	// the name of the publisher, used as the PublisherName of its claims
+	Name string
+	// the url of the publisher's website
+	Homepage string
+	LogoURL  string
+	// the language the publisher writes in, e.g. "en" or "en-US"
+	LanguageCode string
+	// describes how trustworthy the publisher is, e.g. "satire" or "mainstream"
+	TrustLabel string
+}
+
+// Validate returns an error if the publisher has no name or if its name is too long
+func (publisher Publisher) Validate() error {
+	if publisher.Name == "" {
+		return fmt.Errorf("Publisher must have a name")
+	}
+	if utf8.RuneCountInString(publisher.Name) > MaxPublisherNameLength {
+		return fmt.Errorf("Publisher name '%v' is longer than %v characters", publisher.Name, MaxPublisherNameLength)
+	}
+	return nil
+}
+
+// truncatePublisherName shortens a publisher name to MaxPublisherNameLength characters
+func truncatePublisherName(name string) string {
+	return truncate(name, MaxPublisherNameLength)
+}
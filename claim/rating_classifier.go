@@ -0,0 +1,165 @@
+package claim
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RatingClassifier maps the textual rating given to a claim by a publisher to a Verdict
+type RatingClassifier interface {
+	// Classify returns the verdict matching the textual rating given by the publisher hosted on publisherSite,
+	// written in the given language. An error is returned if the rating could not be classified.
+	Classify(publisherSite string, languageCode string, textualRating string) (Verdict, error)
+}
+
+// RatingRule maps textual ratings matching a regex Pattern to a Verdict.
+// A rule can be restricted to certain publisher sites and/or languages.
+type RatingRule struct {
+	// regex matched against the textual rating
+	Pattern string
+	Verdict Verdict
+	// publisher sites (e.g. "politifact.com") the rule applies to. The rule applies to every site if empty
+	Sites []string
+	// language codes (e.g. "en" or "en-US") the rule applies to. The rule applies to every language if empty
+	Languages []string
+}
+
+// DefaultRatingRules returns rules covering the common English textual ratings.
+// More specific ratings (e.g. "Mostly false", "Half true") appear before the generic true/false ones.
+func DefaultRatingRules() []RatingRule {
+	return []RatingRule{
+		{Pattern: `(?i)satir|parody`, Verdict: VerdictSatire},
+		{Pattern: `(?i)unproven|unverified|unsubstantiated|undetermined|no evidence`, Verdict: VerdictUnproven},
+		{Pattern: `(?i)misleading|missing context|out of context|distort|exaggerat`, Verdict: VerdictMisleading},
+		{Pattern: `(?i)mostly.(false|fake|inaccurate)`, Verdict: VerdictMostlyFalse},
+		{Pattern: `(?i)mostly.(true|real|accurate|correct)`, Verdict: VerdictMostlyTrue},
+		{Pattern: `(?i)half.?true|mix|partly|partially`, Verdict: VerdictMixed},
+		{Pattern: `(?i)false|fake|not.(true|real)|pants on fire|incorrect|inaccurate|wrong|hoax|fabricated`, Verdict: VerdictFalse},
+		{Pattern: `(?i)^([^n]|n[^o]|no[^t])*(true|real|correct|accurate)`, Verdict: VerdictTrue},
+	}
+}
+
+// RuleBasedClassifier is a RatingClassifier which evaluates its rules in order, the first matching rule determines the verdict.
+// Ratings which do not match any rule are counted and can be retrieved through UnmatchedRatings.
+type RuleBasedClassifier struct {
+	rules          []compiledRatingRule
+	unmatchedMutex sync.Mutex
+	unmatched      map[UnmatchedRating]int
+}
+
+type compiledRatingRule struct {
+	regex     *regexp.Regexp
+	verdict   Verdict
+	sites     []string
+	languages []string
+}
+
+// NewRuleBasedClassifier creates a classifier evaluating the given rules in order.
+// Returns an error if a rule has an invalid pattern or verdict.
+func NewRuleBasedClassifier(rules []RatingRule) (*RuleBasedClassifier, error) {
+	compiledRules := make([]compiledRatingRule, 0, len(rules))
+	for i, rule := range rules {
+		regex, regexErr := regexp.Compile(rule.Pattern)
+		if regexErr != nil {
+			return nil, fmt.Errorf("Invalid pattern for rating rule %v: %v", i, regexErr)
+		}
+		if !rule.Verdict.IsValid() {
+			return nil, fmt.Errorf("Invalid verdict for rating rule %v: '%v'", i, rule.Verdict)
+		}
+		compiledRules = append(compiledRules, compiledRatingRule{
+			regex:     regex,
+			verdict:   rule.Verdict,
+			sites:     normalizeAll(rule.Sites, normalizeSite),
+			languages: normalizeAll(rule.Languages, strings.ToLower),
+		})
+	}
+	return &RuleBasedClassifier{rules: compiledRules, unmatched: make(map[UnmatchedRating]int)}, nil
+}
+
+// Classify returns the verdict of the first rule matching the textual rating, site and language.
+// An error is returned and the rating is recorded as unmatched if no rule matches.
+func (classifier *RuleBasedClassifier) Classify(publisherSite string, languageCode string, textualRating string) (Verdict, error) {
+	site := normalizeSite(publisherSite)
+	language := strings.ToLower(languageCode)
+	for _, rule := range classifier.rules {
+		if rule.appliesTo(site, language) && rule.regex.MatchString(textualRating) {
+			return rule.verdict, nil
+		}
+	}
+
+	classifier.unmatchedMutex.Lock()
+	classifier.unmatched[UnmatchedRating{Site: site, LanguageCode: language, TextualRating: textualRating}]++
+	classifier.unmatchedMutex.Unlock()
+	return "", fmt.Errorf("Could not find any match for textual rating %s", textualRating)
+}
+
+// UnmatchedRating is a textual rating which could not be classified, along with the site and language it was found in
+type UnmatchedRating struct {
+	Site          string
+	LanguageCode  string
+	TextualRating string
+}
+
+// UnmatchedRatingCount is the number of times an UnmatchedRating was encountered
+type UnmatchedRatingCount struct {
+	UnmatchedRating
+	Count int
+}
+
+// UnmatchedRatings returns the ratings which could not be classified so far, from most to least frequent
+func (classifier *RuleBasedClassifier) UnmatchedRatings() []UnmatchedRatingCount {
+	classifier.unmatchedMutex.Lock()
+	defer classifier.unmatchedMutex.Unlock()
+	counts := make([]UnmatchedRatingCount, 0, len(classifier.unmatched))
+	for rating, count := range classifier.unmatched {
+		counts = append(counts, UnmatchedRatingCount{rating, count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].TextualRating < counts[j].TextualRating
+	})
+	return counts
+}
+
+func (rule compiledRatingRule) appliesTo(site string, language string) bool {
+	if len(rule.sites) > 0 && !contains(rule.sites, site) {
+		return false
+	}
+	if len(rule.languages) == 0 {
+		return true
+	}
+	// a rule for "en" also applies to regional variants such as "en-US"
+	baseLanguage := strings.SplitN(language, "-", 2)[0]
+	return contains(rule.languages, language) || contains(rule.languages, baseLanguage)
+}
+
+// normalizeSite lowercases a publisher site and strips its scheme, "www." prefix and trailing slash
+func normalizeSite(site string) string {
+	site = strings.ToLower(strings.TrimSpace(site))
+	site = strings.TrimPrefix(site, "https://")
+	site = strings.TrimPrefix(site, "http://")
+	site = strings.TrimPrefix(site, "www.")
+	return strings.TrimSuffix(site, "/")
+}
+
+func normalizeAll(values []string, normalize func(string) string) []string {
+	normalized := make([]string, 0, len(values))
+	for _, value := range values {
+		normalized = append(normalized, normalize(value))
+	}
+	return normalized
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
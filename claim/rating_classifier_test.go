@@ -0,0 +1,230 @@
+package claim
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRuleBasedClassifier_Classify_DefaultRules(t *testing.T) {
+	tests := []struct {
+		name          string
+		textualRating string
+		want          Verdict
+		wantErr       bool
+	}{
+		{
+			name:          "parsing 'true' returns true",
+			textualRating: "true",
+			want:          VerdictTrue,
+			wantErr:       false,
+		},
+		{
+			name:          "parsing 'real' returns true",
+			textualRating: "real",
+			want:          VerdictTrue,
+			wantErr:       false,
+		},
+
+		{
+			name:          "parsing 'false' returns false",
+			textualRating: "false",
+			want:          VerdictFalse,
+			wantErr:       false,
+		},
+		{
+			name:          "parsing 'fake' returns false",
+			textualRating: "fake",
+			want:          VerdictFalse,
+			wantErr:       false,
+		},
+		{
+			name:          "parsing 'not true' returns false",
+			textualRating: "not true",
+			want:          VerdictFalse,
+			wantErr:       false,
+		},
+		{
+			name:          "parsing 'not real' returns false",
+			textualRating: "not real",
+			want:          VerdictFalse,
+			wantErr:       false,
+		},
+		{
+			name:          "parsing 'Mostly True' returns mostly-true",
+			textualRating: "Mostly True",
+			want:          VerdictMostlyTrue,
+			wantErr:       false,
+		},
+		{
+			name:          "parsing 'Half true' returns mixed",
+			textualRating: "Half true",
+			want:          VerdictMixed,
+			wantErr:       false,
+		},
+		{
+			name:          "parsing 'Mostly false' returns mostly-false",
+			textualRating: "Mostly false",
+			want:          VerdictMostlyFalse,
+			wantErr:       false,
+		},
+		{
+			name:          "parsing 'Pants on Fire!' returns false",
+			textualRating: "Pants on Fire!",
+			want:          VerdictFalse,
+			wantErr:       false,
+		},
+		{
+			name:          "parsing 'Misleading' returns misleading",
+			textualRating: "Misleading",
+			want:          VerdictMisleading,
+			wantErr:       false,
+		},
+		{
+			name:          "parsing 'Satire' returns satire",
+			textualRating: "Satire",
+			want:          VerdictSatire,
+			wantErr:       false,
+		},
+		{
+			name:          "parsing 'Unproven' returns unproven",
+			textualRating: "Unproven",
+			want:          VerdictUnproven,
+			wantErr:       false,
+		},
+		{
+			name:          "parsing without a match returns an error",
+			textualRating: "STRING_THAT_SHOULD_NEVER_MATCH",
+			want:          "",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := defaultClassifier().Classify("publisher.com", "en", tt.textualRating)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RuleBasedClassifier.Classify() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("RuleBasedClassifier.Classify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleBasedClassifier_Classify(t *testing.T) {
+	rules := []RatingRule{
+		{Pattern: `(?i)^four pinocchios$`, Verdict: VerdictFalse, Sites: []string{"washingtonpost.com"}},
+		{Pattern: `(?i)^faux$`, Verdict: VerdictFalse, Languages: []string{"fr"}},
+		{Pattern: `(?i)true`, Verdict: VerdictTrue},
+	}
+	tests := []struct {
+		name          string
+		site          string
+		languageCode  string
+		textualRating string
+		want          Verdict
+		wantErr       bool
+	}{
+		{
+			name:          "site rule applies to matching site",
+			site:          "https://www.washingtonpost.com/",
+			languageCode:  "en",
+			textualRating: "Four Pinocchios",
+			want:          VerdictFalse,
+			wantErr:       false,
+		},
+		{
+			name:          "site rule does not apply to other sites",
+			site:          "politifact.com",
+			languageCode:  "en",
+			textualRating: "Four Pinocchios",
+			want:          "",
+			wantErr:       true,
+		},
+		{
+			name:          "language rule applies to regional variants",
+			site:          "lemonde.fr",
+			languageCode:  "fr-FR",
+			textualRating: "Faux",
+			want:          VerdictFalse,
+			wantErr:       false,
+		},
+		{
+			name:          "language rule does not apply to other languages",
+			site:          "lemonde.fr",
+			languageCode:  "en",
+			textualRating: "Faux",
+			want:          "",
+			wantErr:       true,
+		},
+		{
+			name:          "unrestricted rule applies to every site and language",
+			site:          "any.com",
+			languageCode:  "",
+			textualRating: "True",
+			want:          VerdictTrue,
+			wantErr:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classifier, _ := NewRuleBasedClassifier(rules)
+			got, err := classifier.Classify(tt.site, tt.languageCode, tt.textualRating)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RuleBasedClassifier.Classify() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("RuleBasedClassifier.Classify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleBasedClassifier_UnmatchedRatings(t *testing.T) {
+	classifier, _ := NewRuleBasedClassifier([]RatingRule{{Pattern: `(?i)true`, Verdict: VerdictTrue}})
+	classifier.Classify("a.com", "en", "True")
+	classifier.Classify("a.com", "en", "Cherry picks")
+	classifier.Classify("www.b.com", "en-US", "Spins the facts")
+	classifier.Classify("b.com", "en-US", "Spins the facts")
+
+	want := []UnmatchedRatingCount{
+		{UnmatchedRating{Site: "b.com", LanguageCode: "en-us", TextualRating: "Spins the facts"}, 2},
+		{UnmatchedRating{Site: "a.com", LanguageCode: "en", TextualRating: "Cherry picks"}, 1},
+	}
+	if got := classifier.UnmatchedRatings(); !reflect.DeepEqual(got, want) {
+		t.Errorf("RuleBasedClassifier.UnmatchedRatings() = %v, want %v", got, want)
+	}
+}
+
+func Test_NewRuleBasedClassifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []RatingRule
+		wantErr bool
+	}{
+		{
+			name:    "Default rules are valid",
+			rules:   DefaultRatingRules(),
+			wantErr: false,
+		},
+		{
+			name:    "Invalid pattern returns error",
+			rules:   []RatingRule{{Pattern: `(true`, Verdict: VerdictTrue}},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid verdict returns error",
+			rules:   []RatingRule{{Pattern: `true`, Verdict: "correct"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRuleBasedClassifier(tt.rules); (err != nil) != tt.wantErr {
+				t.Errorf("NewRuleBasedClassifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package claim
+
+import "time"
+
+// maximum number of characters of the fields of claims and reviews collected from publishers, longer values are truncated
+const (
+	MaxPublisherNameLength = 200
+	MaxClaimantLength      = 200
+	MaxPublisherSiteLength = 200
+	MaxReviewTitleLength   = 500
+	MaxTextualRatingLength = 200
+)
+
+// Review is the evaluation of a claim by a fact-checking publisher
+type Review struct {
+	PublisherName string
+	PublisherSite string
+	// the url to the article evaluating the claim
+	URL string
+	// the title of the article evaluating the claim
+	Title string
+	// the rating as written by the publisher, e.g. "Pants on Fire!"
+	TextualRating string
+	// the verdict the textual rating was classified as
+	Verdict      Verdict
+	LanguageCode string
+	ReviewedAt   time.Time
+}
+
+// truncated returns the review with its publisher name, publisher site, title and textual rating shortened to their maximum length
+func (review Review) truncated() Review {
+	review.PublisherName = truncate(review.PublisherName, MaxPublisherNameLength)
+	review.PublisherSite = truncate(review.PublisherSite, MaxPublisherSiteLength)
+	review.Title = truncate(review.Title, MaxReviewTitleLength)
+	review.TextualRating = truncate(review.TextualRating, MaxTextualRatingLength)
+	return review
+}
+
+// truncate shortens a value to maxLength characters
+func truncate(value string, maxLength int) string {
+	runes := []rune(value)
+	if len(runes) <= maxLength {
+		return value
+	}
+	return string(runes[:maxLength])
+}
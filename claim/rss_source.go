@@ -1,58 +1,172 @@
 package claim
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"time"
+
 	"github.com/mmcdole/gofeed"
 )
 
-// RssSource is a claim source based on RSS feeds. Its claims are always true
+// RssSource is a claim source based on RSS feeds of news or satire headlines
 type RssSource struct {
-	// determines if claims generated by this source are real or fake
-	isRealSource bool
-	// a function which takes a feed URL and returns a structured RSS feed or error
-	parseFeed func(string) (*gofeed.Feed, error)
+	// the kind of claims generated by this source, which determines their verdict
+	kind Kind
+	// stores the validators of fetched feeds so that unchanged feeds are skipped, feeds are always downloaded if nil
+	cache      FeedCache
+	httpClient http.Client
+	// feeds larger than maxFeedBytes are rejected
+	maxFeedBytes int64
+	// the publishers of feeds, keyed by feed URL
+	publishers map[string]Publisher
 }
 
-// NewRssSource creates an RSS based claim source
-func NewRssSource(isRealSource bool) *RssSource {
-	fn := gofeed.NewParser().ParseURL
-	return &RssSource{isRealSource, fn}
+const feedRequestTimeout = 30 * time.Second
+const defaultMaxFeedBytes = 10 << 20
+
+// NewRssSource creates an RSS based claim source generating claims of the given kind, either KindNews or KindSatire. Feeds are downloaded again only if they changed since the validators stored in cache,
+// which can be nil to always download feeds.
+// Claims are attributed to the publisher of their feed in publishers, keyed by feed URL. Feeds without a publisher are named after
+// their first category or their title.
+func NewRssSource(kind Kind, cache FeedCache, publishers map[string]Publisher) *RssSource {
+	return &RssSource{
+		kind:         kind,
+		cache:        cache,
+		httpClient:   http.Client{Timeout: feedRequestTimeout},
+		maxFeedBytes: defaultMaxFeedBytes,
+		publishers:   publishers,
+	}
 }
 
 // GetClaims returns claims that could be parsed for a given publisherURL
-func (rssSource *RssSource) GetClaims(publisherURL string) []Claim {
-	claims := make([]Claim, 0)
-	feed, feedParseErr := rssSource.parseFeed(publisherURL)
-	if feedParseErr != nil {
-		log.Printf("Encountered error while collecting claims for publisher url %s: %s", publisherURL, feedParseErr.Error())
-	} else {
-		publisherName := feed.Title
-		if len(feed.Categories) > 0 {
-			publisherName = feed.Categories[0]
+func (rssSource *RssSource) GetClaims(ctx context.Context, publisherURL string) []Claim {
+	result, err := rssSource.FetchClaims(ctx, publisherURL)
+	logFailures(publisherURL, result, err)
+	return result.Claims
+}
+
+// FetchClaims returns the claims that could be parsed for a given publisherURL, along with the feed items which could not.
+// The result is marked as NotModified and holds no claims if the feed did not change since the validators stored in the cache were returned.
+// The validators of the feed are returned instead of being stored, so that they are only stored once its claims were saved.
+func (rssSource *RssSource) FetchClaims(ctx context.Context, publisherURL string) (FetchResult, error) {
+	result := FetchResult{Claims: make([]Claim, 0)}
+	feed, validators, feedErr := rssSource.fetchFeed(ctx, publisherURL)
+	if feedErr == errFeedNotModified {
+		result.NotModified = true
+		return result, nil
+	}
+	if feedErr != nil {
+		return result, &FetchError{publisherURL, feedErr}
+	}
+
+	publisherName := rssSource.publisherName(publisherURL, feed)
+	result.Fetched = len(feed.Items)
+	for _, article := range feed.Items {
+		reviewedAt := article.PublishedParsed
+		if reviewedAt == nil {
+			reviewedAt = article.UpdatedParsed
+		}
+		if reviewedAt == nil {
+			result.Failures = append(result.Failures, ItemFailure{articleID(article), errMissingReviewDate})
+			continue
 		}
-		for _, article := range feed.Items {
-			reviewedAt := article.PublishedParsed
-			if reviewedAt == nil {
-				reviewedAt = article.UpdatedParsed
-			}
-			if reviewedAt != nil {
-				claimTitle := article.Title
-
-				claim, creationErr := NewClaim(
-					claimTitle,
-					publisherName,
-					article.Link,
-					rssSource.isRealSource,
-					*reviewedAt,
-				)
-				if creationErr == nil {
-					claims = append(claims, claim)
-				} else {
-					log.Printf("RSS %v: %v", publisherURL, creationErr)
-				}
-			}
 
+		claim, creationErr := NewClaim(
+			article.Title,
+			publisherName,
+			article.Link,
+			rssSource.kind,
+			rssSource.verdict(),
+			*reviewedAt,
+		)
+		if creationErr == nil {
+			result.Claims = append(result.Claims, claim)
+		} else {
+			result.Failures = append(result.Failures, ItemFailure{articleID(article), creationErr})
+		}
+	}
+
+	// validators are only returned once the feed was parsed, so that a feed which failed is downloaded again on the next run
+	result.Validators = &validators
+	return result, nil
+}
+
+var errMissingReviewDate = errors.New("Feed item has neither a published nor an updated date")
+
+// errFeedNotModified is returned by fetchFeed if the feed did not change since its cached validators were returned
+var errFeedNotModified = errors.New("Feed was not modified")
+
+// fetchFeed downloads and parses the feed, sending the cached validators of the feed along with the request.
+// Returns errFeedNotModified if the server reports that the feed did not change.
+func (rssSource *RssSource) fetchFeed(ctx context.Context, feedURL string) (*gofeed.Feed, FeedValidators, error) {
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if reqErr != nil {
+		return nil, FeedValidators{}, reqErr
+	}
+	if rssSource.cache != nil {
+		validators, cacheErr := rssSource.cache.GetValidators(feedURL)
+		if cacheErr != nil {
+			log.Printf("Failed to read cached validators of feed %v: %v", feedURL, cacheErr)
+		}
+		if validators.ETag != "" {
+			req.Header.Set("If-None-Match", validators.ETag)
 		}
+		if validators.LastModified != "" {
+			req.Header.Set("If-Modified-Since", validators.LastModified)
+		}
+	}
+
+	resp, respErr := rssSource.httpClient.Do(req)
+	if respErr != nil {
+		return nil, FeedValidators{}, respErr
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, FeedValidators{}, errFeedNotModified
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, FeedValidators{}, fmt.Errorf("Encountered invalid response code while fetching feed: %v", resp.StatusCode)
+	}
+
+	body, readErr := readLimited(resp.Body, rssSource.maxFeedBytes)
+	if readErr != nil {
+		return nil, FeedValidators{}, readErr
+	}
+	feed, parseErr := gofeed.NewParser().Parse(bytes.NewReader(body))
+	if parseErr != nil {
+		return nil, FeedValidators{}, parseErr
+	}
+	validators := FeedValidators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	return feed, validators, nil
+}
+
+// publisherName returns the name of the configured publisher of the feed, falling back to the feed's first category or title
+func (rssSource *RssSource) publisherName(feedURL string, feed *gofeed.Feed) string {
+	if publisher, found := rssSource.publishers[feedURL]; found && publisher.Name != "" {
+		return publisher.Name
+	}
+	if len(feed.Categories) > 0 {
+		return truncatePublisherName(feed.Categories[0])
+	}
+	return truncatePublisherName(feed.Title)
+}
+
+// articleID identifies a feed item by its link, or by its title if it has no link
+func articleID(article *gofeed.Item) string {
+	if article.Link != "" {
+		return article.Link
+	}
+	return article.Title
+}
+
+// verdict returns the verdict given to every claim generated by this source
+func (rssSource *RssSource) verdict() Verdict {
+	if rssSource.kind == KindSatire {
+		return VerdictSatire
 	}
-	return claims
+	return VerdictTrue
 }
@@ -1,24 +1,27 @@
 package claim
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
-
-	"github.com/mmcdole/gofeed"
 )
 
 
 func TestRssSource_GetClaims(t *testing.T) {
 	
 	tests := []struct {
-		name      string
-		rssSource *RssSource
-		want      []Claim
+		name string
+		feed string
+		want []Claim
 	}{
 		{
 			name: "Correctly maps rss feed tags",
-			rssSource: rssSourceWithFeed(`
+			feed: `
 			<?xml version="1.0" encoding="UTF-8"?>
 			<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
 				<category term="publisher_name"/>
@@ -34,20 +37,22 @@ func TestRssSource_GetClaims(t *testing.T) {
 					<title>second article title</title>
 				</entry>
 			</feed>
-			`),
+			`,
 			want: []Claim{
 				claim(
 					"article title",
 					"publisher_name",
 					"http://article_url.com",
-					true,
+					KindNews,
+					VerdictTrue,
 					time.Date(2020, 8, 6, 23, 20, 42, 0, time.UTC),
 				),
 				claim(
 					"second article title",
 					"publisher_name",
 					"http://second_article_url.com",
-					true,
+					KindNews,
+					VerdictTrue,
 					time.Date(2021, 10, 3, 5, 0, 15, 0, time.UTC),
 				),
 				
@@ -55,7 +60,7 @@ func TestRssSource_GetClaims(t *testing.T) {
 		},
 		{
 			name: "Uses 'pubDate' for review date if 'updated' tag not available",
-			rssSource: rssSourceWithFeed(`
+			feed: `
 			<rss xmlns:atom="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/" version="2.0">
 				<channel>
 					<link>http://publisher_site.com</link>
@@ -67,20 +72,21 @@ func TestRssSource_GetClaims(t *testing.T) {
 					</item>
 				</channel>
 			</rss>
-			`),
+			`,
 			want: []Claim{
 				claim(
 					"article title",
 					"publisher_name",
 					"http://article_url.com",
-					true,
+					KindNews,
+					VerdictTrue,
 					time.Date(2020, 8, 2, 15, 13, 0, 0, time.UTC),
 				),
 			},
 		},
 		{
 			name: "Excludes items missing title, link, or review date tags",
-			rssSource: rssSourceWithFeed(`
+			feed: `
 			<rss xmlns:atom="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/" version="2.0">
 				<channel>
 					<link>http://publisher_site.com</link>
@@ -99,23 +105,238 @@ func TestRssSource_GetClaims(t *testing.T) {
 					</item>
 				</channel>
 			</rss>
-			`),
+			`,
 			want: []Claim{},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.rssSource.GetClaims("any publisher url"); !reflect.DeepEqual(got, tt.want) {
+			feedURL := feedServer(t, tt.feed).URL
+			if got := NewRssSource(KindNews, nil, nil).GetClaims(context.Background(), feedURL); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("RssSource.GetClaims() = %#v, want %#v", got, tt.want)
 			}
 		})
 	}
 }
 
-// returns an RssSource which will read from a hardcoded feed as a string regardless of which publisher url is passed 
-func rssSourceWithFeed(feed string) *RssSource {
-	parseFromTextFunc := func(string) (*gofeed.Feed, error) {
-		return gofeed.NewParser().ParseString(feed)
+// returns a server serving a hardcoded feed as a string regardless of which path is requested, closed at the end of the test
+func feedServer(t *testing.T, feed string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(feed))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRssSource_FetchClaims(t *testing.T) {
+	feedURL := feedServer(t, `
+	<rss version="2.0">
+		<channel>
+			<category>publisher_name</category>
+			<item>
+				<title>article title</title>
+				<link>http://article_url.com</link>
+				<pubDate>Sun, 02 Aug 2020 15:13:00 +0000</pubDate>
+			</item>
+			<item>
+				<title>article missing review date</title>
+				<link>http://article_missing_review_date.com</link>
+			</item>
+			<item>
+				<link>http://article_missing_title.com</link>
+				<pubDate>Sun, 02 Aug 2020 15:13:00 +0000</pubDate>
+			</item>
+		</channel>
+	</rss>
+	`).URL
+	result, err := NewRssSource(KindNews, nil, nil).FetchClaims(context.Background(), feedURL)
+	if err != nil {
+		t.Fatalf("RssSource.FetchClaims() error = %v, want nil", err)
+	}
+	if result.Fetched != 3 || len(result.Claims) != 1 {
+		t.Errorf("RssSource.FetchClaims() fetched %v items and %v claims, want 3 items and 1 claim", result.Fetched, len(result.Claims))
+	}
+	wantFailedItems := []string{"http://article_missing_review_date.com", "http://article_missing_title.com"}
+	gotFailedItems := []string{}
+	for _, failure := range result.Failures {
+		gotFailedItems = append(gotFailedItems, failure.Item)
+	}
+	if !reflect.DeepEqual(gotFailedItems, wantFailedItems) {
+		t.Errorf("RssSource.FetchClaims() failed items = %v, want %v", gotFailedItems, wantFailedItems)
+	}
+}
+
+func TestRssSource_FetchClaims_FeedError(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		rssSource *RssSource
+	}{
+		{
+			name:      "Invalid feed",
+			handler:   func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("not a feed")) },
+			rssSource: NewRssSource(KindNews, nil, nil),
+		},
+		{
+			name:      "Invalid response code",
+			handler:   func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) },
+			rssSource: NewRssSource(KindNews, nil, nil),
+		},
+		{
+			name:      "Feed larger than the size limit",
+			handler:   func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(strings.Repeat("a", 101))) },
+			rssSource: &RssSource{kind: KindNews, maxFeedBytes: 100},
+		},
+		{
+			name: "Feed slower than the timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+			},
+			rssSource: &RssSource{kind: KindNews, httpClient: http.Client{Timeout: 10 * time.Millisecond}, maxFeedBytes: defaultMaxFeedBytes},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+			_, err := tt.rssSource.FetchClaims(context.Background(), server.URL)
+			var fetchErr *FetchError
+			if !errors.As(err, &fetchErr) || fetchErr.Publisher != server.URL {
+				t.Errorf("RssSource.FetchClaims() error = %v, want FetchError for %v", err, server.URL)
+			}
+		})
+	}
+}
+
+func TestRssSource_FetchClaims_ConditionalGet(t *testing.T) {
+	const etag = `"v1"`
+	const lastModified = "Sun, 02 Aug 2020 15:13:00 GMT"
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte(`
+		<rss version="2.0">
+			<channel>
+				<category>publisher_name</category>
+				<item>
+					<title>article title</title>
+					<link>http://article_url.com</link>
+					<pubDate>Sun, 02 Aug 2020 15:13:00 +0000</pubDate>
+				</item>
+			</channel>
+		</rss>
+		`))
+	}))
+	defer server.Close()
+	cache := make(mockFeedCache)
+	rssSource := NewRssSource(KindNews, cache, nil)
+
+	result, err := rssSource.FetchClaims(context.Background(), server.URL)
+	if err != nil || result.NotModified || len(result.Claims) != 1 {
+		t.Fatalf("First RssSource.FetchClaims() = %#v, %v, want 1 claim", result, err)
 	}
-	return &RssSource{true, parseFromTextFunc}
-}
\ No newline at end of file
+	if want := (FeedValidators{ETag: etag, LastModified: lastModified}); result.Validators == nil || *result.Validators != want {
+		t.Fatalf("Returned validators = %#v, want %#v", result.Validators, want)
+	}
+	if len(cache) != 0 {
+		t.Errorf("Cached validators = %#v, want none until the claims are saved", cache)
+	}
+	cache.SaveValidators(server.URL, *result.Validators)
+
+	result, err = rssSource.FetchClaims(context.Background(), server.URL)
+	if err != nil || !result.NotModified || len(result.Claims) != 0 || result.Validators != nil {
+		t.Errorf("Second RssSource.FetchClaims() = %#v, %v, want a not modified result without claims", result, err)
+	}
+	if requests != 2 {
+		t.Errorf("Requests = %v, want 2", requests)
+	}
+}
+
+type mockFeedCache map[string]FeedValidators
+
+func (cache mockFeedCache) GetValidators(feedURL string) (FeedValidators, error) {
+	return cache[feedURL], nil
+}
+
+func (cache mockFeedCache) SaveValidators(feedURL string, validators FeedValidators) error {
+	cache[feedURL] = validators
+	return nil
+}
+
+func TestRssSource_FetchClaims_PublisherName(t *testing.T) {
+	longTitle := strings.Repeat("a", MaxPublisherNameLength+10)
+	feedURL := feedServer(t, `
+	<rss version="2.0">
+		<channel>
+			<title>`+longTitle+`</title>
+			<item>
+				<title>article title</title>
+				<link>http://article_url.com</link>
+				<pubDate>Sun, 02 Aug 2020 15:13:00 +0000</pubDate>
+			</item>
+		</channel>
+	</rss>
+	`).URL
+	tests := []struct {
+		name       string
+		publishers map[string]Publisher
+		want       string
+	}{
+		{"Uses the name of the feed's publisher", map[string]Publisher{feedURL: {Name: "Publisher"}}, "Publisher"},
+		{"Truncates the feed's title without a publisher", nil, longTitle[:MaxPublisherNameLength]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewRssSource(KindNews, nil, tt.publishers).FetchClaims(context.Background(), feedURL)
+			if err != nil || len(result.Claims) != 1 {
+				t.Fatalf("RssSource.FetchClaims() = %#v, %v, want 1 claim", result, err)
+			}
+			if got := result.Claims[0].PublisherName; got != tt.want {
+				t.Errorf("PublisherName = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRssSource_FetchClaims_Kind(t *testing.T) {
+	feedURL := feedServer(t, `
+	<rss version="2.0">
+		<channel>
+			<category>publisher_name</category>
+			<item>
+				<title>article title</title>
+				<link>http://article_url.com</link>
+				<pubDate>Sun, 02 Aug 2020 15:13:00 +0000</pubDate>
+			</item>
+		</channel>
+	</rss>
+	`).URL
+	tests := []struct {
+		name string
+		kind Kind
+		want Claim
+	}{
+		{"News headlines are facts", KindNews, claim("article title", "publisher_name", "http://article_url.com", KindNews, VerdictTrue, time.Date(2020, 8, 2, 15, 13, 0, 0, time.UTC))},
+		{"Satire headlines are satire", KindSatire, claim("article title", "publisher_name", "http://article_url.com", KindSatire, VerdictSatire, time.Date(2020, 8, 2, 15, 13, 0, 0, time.UTC))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewRssSource(tt.kind, nil, nil).FetchClaims(context.Background(), feedURL)
+			if err != nil || len(result.Claims) != 1 {
+				t.Fatalf("RssSource.FetchClaims() = %#v, %v, want 1 claim", result, err)
+			}
+			if !reflect.DeepEqual(result.Claims[0], tt.want) {
+				t.Errorf("RssSource.FetchClaims() claim = %#v, want %#v", result.Claims[0], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package claim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+// formats of the listing pages scraped by a ScrapeSource
+const (
+	ScrapeFormatHTML = "html"
+	ScrapeFormatJSON = "json"
+)
+
+// ScrapeConfig describes how the claim reviews listed on a page of a fact-checking site are extracted.
+// Fields are selected through CSS selectors in HTML pages, or through JSONPath expressions in JSON pages.
+type ScrapeConfig struct {
+	// the format of the listing page, ScrapeFormatHTML or ScrapeFormatJSON. Defaults to ScrapeFormatHTML
+	Format string
+	// selects the elements of the listing page holding one claim review each
+	Items string
+	// select the title, link, review date and textual rating within an item. HTML selectors can end with "@attribute"
+	// to extract an attribute instead of the text of the element, e.g. "a.title@href". An empty HTML selector selects the item itself
+	Title  string
+	Link   string
+	Date   string
+	Rating string
+	// the Go time layout of review dates, e.g. "January 2, 2006". Defaults to time.RFC3339
+	DateLayout string
+	// the publisher of the site. Its language is used to classify textual ratings
+	Publisher Publisher
+}
+
+// Validate returns an error if the publisher, format or one of the selectors is invalid
+func (config ScrapeConfig) Validate() error {
+	if err := config.Publisher.Validate(); err != nil {
+		return err
+	}
+	if config.Items == "" || config.Title == "" || config.Link == "" || config.Date == "" || config.Rating == "" {
+		return errors.New("Items, Title, Link, Date and Rating selectors are required")
+	}
+	selectors := []string{config.Items, config.Title, config.Link, config.Date, config.Rating}
+	switch config.format() {
+	case ScrapeFormatHTML:
+		for _, selector := range selectors {
+			if cssSelector, _ := splitAttribute(selector); cssSelector != "" {
+				if _, err := cascadia.Compile(cssSelector); err != nil {
+					return fmt.Errorf("Invalid CSS selector '%v': %v", selector, err)
+				}
+			}
+		}
+	case ScrapeFormatJSON:
+		for _, selector := range selectors {
+			if _, err := parseJSONPath(selector); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("Invalid format '%v'", config.Format)
+	}
+	return nil
+}
+
+func (config ScrapeConfig) format() string {
+	if config.Format == "" {
+		return ScrapeFormatHTML
+	}
+	return strings.ToLower(config.Format)
+}
+
+func (config ScrapeConfig) dateLayout() string {
+	if config.DateLayout == "" {
+		return time.RFC3339
+	}
+	return config.DateLayout
+}
+
+// ScrapeSource is a claim source scraping the listing pages of fact-checking sites which have neither a feed nor an API
+type ScrapeSource struct {
+	// maps the textual ratings of claim reviews to verdicts
+	classifier RatingClassifier
+	// the scraping configuration of each listing page, keyed by URL
+	configs    map[string]ScrapeConfig
+	httpClient http.Client
+	// pages larger than maxPageBytes are rejected
+	maxPageBytes int64
+}
+
+const pageRequestTimeout = 30 * time.Second
+const defaultMaxPageBytes = 10 << 20
+
+// NewScrapeSource creates a claim source scraping listing pages according to configs, keyed by listing page URL
+func NewScrapeSource(classifier RatingClassifier, configs map[string]ScrapeConfig) *ScrapeSource {
+	return &ScrapeSource{
+		classifier:   classifier,
+		configs:      configs,
+		httpClient:   http.Client{Timeout: pageRequestTimeout},
+		maxPageBytes: defaultMaxPageBytes,
+	}
+}
+
+// GetClaims returns claims that could be parsed for a given listingURL
+func (scrapeSource *ScrapeSource) GetClaims(ctx context.Context, listingURL string) []Claim {
+	result, err := scrapeSource.FetchClaims(ctx, listingURL)
+	logFailures(listingURL, result, err)
+	return result.Claims
+}
+
+// FetchClaims returns the claims that could be parsed from the listing page, along with the items which could not
+func (scrapeSource *ScrapeSource) FetchClaims(ctx context.Context, listingURL string) (FetchResult, error) {
+	result := FetchResult{Claims: make([]Claim, 0)}
+	config, found := scrapeSource.configs[listingURL]
+	if !found {
+		return result, &FetchError{listingURL, errNoScrapeConfig}
+	}
+	if configErr := config.Validate(); configErr != nil {
+		return result, &FetchError{listingURL, fmt.Errorf("Invalid scrape configuration: %v", configErr)}
+	}
+	page, pageErr := fetchPage(ctx, &scrapeSource.httpClient, listingURL, scrapeSource.maxPageBytes)
+	if pageErr != nil {
+		return result, &FetchError{listingURL, pageErr}
+	}
+	items, extractErr := extractItems(page, config)
+	if extractErr != nil {
+		return result, &FetchError{listingURL, extractErr}
+	}
+
+	result.Fetched = len(items)
+	for _, item := range items {
+		claim, claimErr := scrapeSource.asClaim(listingURL, config, item)
+		if claimErr == nil {
+			result.Claims = append(result.Claims, claim)
+		} else {
+			result.Failures = append(result.Failures, ItemFailure{item.id(), claimErr})
+		}
+	}
+	return result, nil
+}
+
+var errNoScrapeConfig = errors.New("No scrape configuration for listing page")
+
+// scrapedItem holds the fields extracted from an item of a listing page, as written on the page
+type scrapedItem struct {
+	title  string
+	link   string
+	date   string
+	rating string
+}
+
+// id identifies an item by its link, or by its title if it has no link
+func (item scrapedItem) id() string {
+	if item.link != "" {
+		return item.link
+	}
+	return item.title
+}
+
+// asClaim converts an item of the listing page to a claim whose single review is the item itself
+func (scrapeSource *ScrapeSource) asClaim(listingURL string, config ScrapeConfig, item scrapedItem) (Claim, error) {
+	base, baseErr := url.Parse(listingURL)
+	if baseErr != nil {
+		return Claim{}, baseErr
+	}
+	if item.link == "" {
+		return Claim{}, errors.New("Item has no link")
+	}
+	// links are often relative to the listing page
+	link, linkErr := base.Parse(item.link)
+	if linkErr != nil {
+		return Claim{}, fmt.Errorf("Invalid link '%v': %v", item.link, linkErr)
+	}
+	reviewedAt, dateErr := time.Parse(config.dateLayout(), item.date)
+	if dateErr != nil {
+		return Claim{}, fmt.Errorf("Invalid review date '%v': %v", item.date, dateErr)
+	}
+	publisher := config.Publisher
+	verdict, classifyErr := scrapeSource.classifier.Classify(base.Host, publisher.LanguageCode, item.rating)
+	if classifyErr != nil {
+		return Claim{}, classifyErr
+	}
+
+	claim, creationErr := NewClaim(item.title, publisher.Name, link.String(), KindFactCheck, verdict, reviewedAt)
+	if creationErr != nil {
+		return Claim{}, creationErr
+	}
+	claim.Reviews = []Review{Review{
+		PublisherName: publisher.Name,
+		PublisherSite: base.Host,
+		URL:           claim.URL,
+		Title:         item.title,
+		TextualRating: item.rating,
+		Verdict:       verdict,
+		LanguageCode:  publisher.LanguageCode,
+		ReviewedAt:    reviewedAt,
+	}.truncated()}
+	return claim, nil
+}
+
+// extractItems extracts the fields of every item listed on the page
+func extractItems(page []byte, config ScrapeConfig) ([]scrapedItem, error) {
+	if config.format() == ScrapeFormatJSON {
+		return extractJSONItems(page, config)
+	}
+	return extractHTMLItems(page, config)
+}
+
+func extractHTMLItems(page []byte, config ScrapeConfig) ([]scrapedItem, error) {
+	document, parseErr := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	items := make([]scrapedItem, 0)
+	document.Find(config.Items).Each(func(_ int, selection *goquery.Selection) {
+		items = append(items, scrapedItem{
+			title:  htmlField(selection, config.Title),
+			link:   htmlField(selection, config.Link),
+			date:   htmlField(selection, config.Date),
+			rating: htmlField(selection, config.Rating),
+		})
+	})
+	return items, nil
+}
+
+// htmlField returns the whitespace-normalized text or attribute of the first element selected within the item
+func htmlField(item *goquery.Selection, selector string) string {
+	cssSelector, attribute := splitAttribute(selector)
+	selection := item
+	if cssSelector != "" {
+		selection = item.Find(cssSelector).First()
+	}
+	return htmlText(selection, attribute)
+}
+
+// htmlFields returns the whitespace-normalized text or attribute of every element selected within the item
+func htmlFields(item *goquery.Selection, selector string) []string {
+	cssSelector, attribute := splitAttribute(selector)
+	selection := item
+	if cssSelector != "" {
+		selection = item.Find(cssSelector)
+	}
+	return selection.Map(func(_ int, element *goquery.Selection) string {
+		return htmlText(element, attribute)
+	})
+}
+
+// htmlText returns the whitespace-normalized text of the selection, or its attribute if an attribute name is given
+func htmlText(selection *goquery.Selection, attribute string) string {
+	text := selection.Text()
+	if attribute != "" {
+		text = selection.AttrOr(attribute, "")
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// splitAttribute splits a selector such as "a.title@href" into its CSS selector and attribute name
+func splitAttribute(selector string) (string, string) {
+	if at := strings.LastIndex(selector, "@"); at >= 0 {
+		return strings.TrimSpace(selector[:at]), strings.TrimSpace(selector[at+1:])
+	}
+	return strings.TrimSpace(selector), ""
+}
+
+func extractJSONItems(page []byte, config ScrapeConfig) ([]scrapedItem, error) {
+	var document interface{}
+	if err := json.Unmarshal(page, &document); err != nil {
+		return nil, err
+	}
+	itemsPath, _ := parseJSONPath(config.Items)
+	items := make([]scrapedItem, 0)
+	for _, item := range evaluateJSONPath(document, itemsPath) {
+		items = append(items, scrapedItem{
+			title:  jsonField(item, config.Title),
+			link:   jsonField(item, config.Link),
+			date:   jsonField(item, config.Date),
+			rating: jsonField(item, config.Rating),
+		})
+	}
+	return items, nil
+}
+
+// jsonField returns the first scalar value selected within the item, or an empty string if there is none
+func jsonField(item interface{}, path string) string {
+	// paths are validated along with the configuration before items are extracted
+	segments, _ := parseJSONPath(path)
+	for _, value := range evaluateJSONPath(item, segments) {
+		if text := jsonString(value); text != "" {
+			return text
+		}
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+package claim
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// returns a server serving the given testdata file regardless of which path is requested, closed at the end of the test
+func fixtureServer(t *testing.T, fixture string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/"+fixture)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestScrapeSource_FetchClaims(t *testing.T) {
+	publisher := Publisher{Name: "Fact Checker", LanguageCode: "en"}
+	tests := []struct {
+		name    string
+		fixture string
+		config  ScrapeConfig
+		// the links of the items expected to fail, as written on the page
+		wantFailedItems []string
+	}{
+		{
+			name:    "Scrapes HTML listing pages through CSS selectors",
+			fixture: "scrape_listing.html",
+			config: ScrapeConfig{
+				Items:     "li.fact-check",
+				Title:     "a.title",
+				Link:      "a.title@href",
+				Date:      "time@datetime",
+				Rating:    ".rating",
+				Publisher: publisher,
+			},
+			wantFailedItems: []string{"/checks/no-date", "/checks/odd-rating"},
+		},
+		{
+			name:    "Scrapes JSON listing pages through JSONPath expressions",
+			fixture: "scrape_listing.json",
+			config: ScrapeConfig{
+				Format:     ScrapeFormatJSON,
+				Items:      "$.data.reviews[*]",
+				Title:      "headline",
+				Link:       "url",
+				Date:       "published",
+				Rating:     "$.verdict.label",
+				DateLayout: "2006-01-02",
+				Publisher:  publisher,
+			},
+			wantFailedItems: []string{"/checks/no-date"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fixtureServer(t, tt.fixture)
+			serverURL, _ := url.Parse(server.URL)
+			scrapeSource := NewScrapeSource(defaultClassifier(), map[string]ScrapeConfig{server.URL: tt.config})
+
+			result, err := scrapeSource.FetchClaims(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("ScrapeSource.FetchClaims() error = %v, want nil", err)
+			}
+
+			review := func(title string, url string, rating string, verdict Verdict, reviewedAt time.Time) Claim {
+				return withReviews(claim(title, publisher.Name, url, KindFactCheck, verdict, reviewedAt), "", time.Time{}, Review{
+					PublisherName: publisher.Name,
+					PublisherSite: serverURL.Host,
+					URL:           url,
+					Title:         title,
+					TextualRating: rating,
+					Verdict:       verdict,
+					LanguageCode:  publisher.LanguageCode,
+					ReviewedAt:    reviewedAt,
+				})
+			}
+			wantDate := func(day int, hour int, minute int) time.Time {
+				if tt.config.DateLayout != "" {
+					hour, minute = 0, 0
+				}
+				return time.Date(2020, 8, day, hour, minute, 0, 0, time.UTC)
+			}
+			wantClaims := []Claim{
+				review("The moon is made of cheese", server.URL+"/checks/moon-cheese", "Pants on Fire", VerdictFalse, wantDate(6, 10, 0)),
+				review("Water is wet", "https://factchecker.example/checks/water-wet", "Mostly True", VerdictMostlyTrue, wantDate(5, 9, 30)),
+			}
+			if !reflect.DeepEqual(result.Claims, wantClaims) {
+				t.Errorf("ScrapeSource.FetchClaims() claims = %#v, want %#v", result.Claims, wantClaims)
+			}
+			if result.Fetched != len(wantClaims)+len(tt.wantFailedItems) {
+				t.Errorf("ScrapeSource.FetchClaims() fetched = %v, want %v", result.Fetched, len(wantClaims)+len(tt.wantFailedItems))
+			}
+			gotFailedItems := []string{}
+			for _, failure := range result.Failures {
+				gotFailedItems = append(gotFailedItems, failure.Item)
+			}
+			if !reflect.DeepEqual(gotFailedItems, tt.wantFailedItems) {
+				t.Errorf("ScrapeSource.FetchClaims() failed items = %v, want %v", gotFailedItems, tt.wantFailedItems)
+			}
+		})
+	}
+}
+
+func TestScrapeSource_FetchClaims_Errors(t *testing.T) {
+	server := fixtureServer(t, "scrape_listing.html")
+	validConfig := ScrapeConfig{Items: "li", Title: "a", Link: "a@href", Date: "time", Rating: ".rating", Publisher: Publisher{Name: "Fact Checker"}}
+	invalidConfig := validConfig
+	invalidConfig.Items = "li["
+	tests := []struct {
+		name    string
+		configs map[string]ScrapeConfig
+	}{
+		{"Listing page without configuration", map[string]ScrapeConfig{}},
+		{"Invalid configuration", map[string]ScrapeConfig{server.URL: invalidConfig}},
+		{"Invalid JSON page", map[string]ScrapeConfig{server.URL: func() ScrapeConfig {
+			config := validConfig
+			config.Format = ScrapeFormatJSON
+			return config
+		}()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewScrapeSource(defaultClassifier(), tt.configs).FetchClaims(context.Background(), server.URL)
+			var fetchErr *FetchError
+			if !errors.As(err, &fetchErr) || fetchErr.Publisher != server.URL {
+				t.Errorf("ScrapeSource.FetchClaims() error = %v, want FetchError for %v", err, server.URL)
+			}
+		})
+	}
+}
+
+func TestScrapeConfig_Validate(t *testing.T) {
+	valid := ScrapeConfig{Items: "li", Title: "a", Link: "a@href", Date: "time", Rating: ".rating", Publisher: Publisher{Name: "Fact Checker"}}
+	tests := []struct {
+		name    string
+		modify  func(config *ScrapeConfig)
+		wantErr bool
+	}{
+		{"Valid HTML configuration", func(config *ScrapeConfig) {}, false},
+		{"Valid JSON configuration", func(config *ScrapeConfig) { config.Format = "JSON"; config.Items = "$.items[*]" }, false},
+		{"Missing selector", func(config *ScrapeConfig) { config.Rating = "" }, true},
+		{"Invalid CSS selector", func(config *ScrapeConfig) { config.Title = "a[" }, true},
+		{"Invalid JSONPath", func(config *ScrapeConfig) { config.Format = ScrapeFormatJSON; config.Items = "$.items[" }, true},
+		{"Unknown format", func(config *ScrapeConfig) { config.Format = "xml" }, true},
+		{"Publisher without name", func(config *ScrapeConfig) { config.Publisher.Name = "" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.modify(&config)
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("ScrapeConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
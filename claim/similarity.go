@@ -0,0 +1,132 @@
+package claim
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// NearDuplicateSimilarity is the minimum TitleSimilarity of two claims considered to be the same claim.
+// Lower thresholds group claims about different people sharing the rest of their wording, e.g. "Biden says taxes will rise"
+// and "Trump says taxes will rise" have a similarity of 0.66
+const NearDuplicateSimilarity = 0.7
+
+const (
+	// the number of characters of a shingle
+	shingleLength = 3
+	// the number of rows of the MinHash signature hashed together into a band
+	bandRows = 3
+	// the number of bands of a title, its MinHash signature having bandCount * bandRows values.
+	// Titles with a similarity of 0.7 share a band with a probability over 99.9%, titles with a similarity of 0.3 with a probability of 42%
+	bandCount = 20
+)
+
+// the seeds of the hash functions of MinHash signatures. They must not change since title bands are stored
+var minHashSeeds = func() []uint64 {
+	seeds := make([]uint64, bandCount*bandRows)
+	for i := range seeds {
+		seeds[i] = mix64(uint64(i + 1))
+	}
+	return seeds
+}()
+
+// NormalizeTitle lowercases the title, drops apostrophes and replaces every other rune which is neither a letter nor a digit by a space,
+// then collapses whitespace so that titles differing only by case or punctuation are equal
+func NormalizeTitle(title string) string {
+	normalized := strings.Map(func(r rune) rune {
+		switch {
+		case r == '\'' || r == '’':
+			return -1
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, title)
+	return strings.Join(strings.Fields(normalized), " ")
+}
+
+// TitleSimilarity returns the Jaccard similarity of the character shingles of the normalized titles,
+// from 0 for titles without any shingle in common to 1 for titles which are equal once normalized
+func TitleSimilarity(a string, b string) float64 {
+	shinglesA, shinglesB := shingles(NormalizeTitle(a)), shingles(NormalizeTitle(b))
+	if len(shinglesA) == 0 || len(shinglesB) == 0 {
+		return 0
+	}
+	common := 0
+	for shingle := range shinglesA {
+		if shinglesB[shingle] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(shinglesA)+len(shinglesB)-common)
+}
+
+// AreNearDuplicates returns true if the titles are similar enough to be considered the same claim
+func AreNearDuplicates(a string, b string) bool {
+	return TitleSimilarity(a, b) >= NearDuplicateSimilarity
+}
+
+// TitleBands returns the locality-sensitive hashes of the title's MinHash signature.
+// Similar titles are likely to share a band, so that comparing a title to the titles sharing one of its bands
+// finds most of its near duplicates without comparing it to every title. Returns nil for titles without letters or digits
+func TitleBands(title string) []int64 {
+	titleShingles := shingles(NormalizeTitle(title))
+	if len(titleShingles) == 0 {
+		return nil
+	}
+	signature := make([]uint64, len(minHashSeeds))
+	for i := range signature {
+		signature[i] = ^uint64(0)
+	}
+	for shingle := range titleShingles {
+		shingleHash := fnv.New64a()
+		shingleHash.Write([]byte(shingle))
+		base := shingleHash.Sum64()
+		for i, seed := range minHashSeeds {
+			if hash := mix64(base ^ seed); hash < signature[i] {
+				signature[i] = hash
+			}
+		}
+	}
+
+	bands := make([]int64, 0, bandCount)
+	buffer := make([]byte, 8)
+	for band := 0; band < bandCount; band++ {
+		bandHash := fnv.New64a()
+		bandHash.Write([]byte{byte(band)})
+		for _, value := range signature[band*bandRows : (band+1)*bandRows] {
+			binary.LittleEndian.PutUint64(buffer, value)
+			bandHash.Write(buffer)
+		}
+		bands = append(bands, int64(bandHash.Sum64()))
+	}
+	return bands
+}
+
+// shingles returns the set of substrings of shingleLength runes of the normalized title, or the title itself if it is shorter
+func shingles(normalized string) map[string]bool {
+	runes := []rune(normalized)
+	set := make(map[string]bool)
+	if len(runes) == 0 {
+		return set
+	}
+	if len(runes) <= shingleLength {
+		set[normalized] = true
+		return set
+	}
+	for i := 0; i+shingleLength <= len(runes); i++ {
+		set[string(runes[i:i+shingleLength])] = true
+	}
+	return set
+}
+
+// mix64 is the finalizer of SplitMix64, which spreads every bit of its input over its output
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
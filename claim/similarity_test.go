@@ -0,0 +1,98 @@
+package claim
+
+import "testing"
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{title: "The Pope endorsed Donald Trump", want: "the pope endorsed donald trump"},
+		{title: "  \"Biden's\"   plan: 5G towers, everywhere!? ", want: "bidens plan 5g towers everywhere"},
+		{title: "Le président a-t-il menti ?", want: "le président a t il menti"},
+		{title: "?!", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := NormalizeTitle(tt.title); got != tt.want {
+				t.Errorf("NormalizeTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAreNearDuplicates(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want bool
+	}{
+		{
+			name: "titles differing by case and punctuation",
+			a:    "NASA confirms: six days of darkness in December!",
+			b:    "nasa confirms six days of darkness in december",
+			want: true,
+		},
+		{
+			name: "titles differing by a few words",
+			a:    "Photo shows Biden falling down the stairs of Air Force One",
+			b:    "Photo shows Joe Biden falling down stairs of Air Force One",
+			want: true,
+		},
+		{
+			name: "titles about different people",
+			a:    "Biden says taxes will rise",
+			b:    "Trump says taxes will rise",
+			want: false,
+		},
+		{
+			name: "unrelated titles",
+			a:    "5G towers spread the coronavirus",
+			b:    "Drinking hot water cures COVID-19",
+			want: false,
+		},
+		{
+			name: "titles without letters or digits",
+			a:    "?!",
+			b:    "?!",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AreNearDuplicates(tt.a, tt.b); got != tt.want {
+				t.Errorf("AreNearDuplicates() = %v (similarity %v), want %v", got, TitleSimilarity(tt.a, tt.b), tt.want)
+			}
+		})
+	}
+}
+
+func TestTitleBands(t *testing.T) {
+	sharedBands := func(a string, b string) int {
+		bandsA, bandsB := TitleBands(a), TitleBands(b)
+		shared := 0
+		for i := range bandsA {
+			if bandsA[i] == bandsB[i] {
+				shared++
+			}
+		}
+		return shared
+	}
+
+	if got := len(TitleBands("The Pope endorsed Donald Trump")); got != bandCount {
+		t.Errorf("TitleBands() returned %v bands, want %v", got, bandCount)
+	}
+	if got := sharedBands("The Pope endorsed Donald Trump", "the pope endorsed donald trump!"); got != bandCount {
+		t.Errorf("Titles equal once normalized share %v bands, want %v", got, bandCount)
+	}
+	if got := sharedBands("The Pope endorsed Donald Trump for president", "Pope Francis endorsed Donald Trump for president"); got == 0 {
+		t.Errorf("Near duplicate titles share no band")
+	}
+	if got := sharedBands("5G towers spread the coronavirus", "Drinking hot water cures COVID-19"); got != 0 {
+		t.Errorf("Unrelated titles share %v bands, want 0", got)
+	}
+	if got := TitleBands("?!"); got != nil {
+		t.Errorf("TitleBands() = %v for a title without letters or digits, want nil", got)
+	}
+}
@@ -0,0 +1,78 @@
+package claim
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// SourceV2 enables the retrieval of Claims for a given publisher while reporting why claims could not be retrieved.
+// The publisher could be a URL or domain depending on the implementation.
+type SourceV2 interface {
+	// FetchClaims returns the claims that could be parsed for the given publisher along with the items which could not.
+	// A *FetchError is returned if the publisher could not be fetched, in which case the result holds the claims retrieved before the failure.
+	FetchClaims(ctx context.Context, publisher string) (FetchResult, error)
+}
+
+// FetchResult holds the claims retrieved for a publisher and the items which could not be converted to claims
+type FetchResult struct {
+	// the number of items returned by the publisher, whether or not they could be converted to claims
+	Fetched  int
+	Claims   []Claim
+	Failures []ItemFailure
+	// true if the publisher reported that nothing changed since it was last fetched, in which case no items are returned
+	NotModified bool
+	// the validators of the fetched feed, to be saved in the FeedCache once its claims were saved so that a feed whose claims
+	// could not be saved is downloaded again. Nil if the publisher is not a feed or did not change
+	Validators *FeedValidators
+}
+
+// ItemFailure describes an item returned by a publisher which could not be converted to a claim
+type ItemFailure struct {
+	// identifies the item, e.g. by its URL or title
+	Item string
+	Err  error
+}
+
+func (failure ItemFailure) String() string {
+	return fmt.Sprintf("%v: %v", failure.Item, failure.Err)
+}
+
+// FetchError is returned when the claims of a publisher could not be fetched
+type FetchError struct {
+	Publisher string
+	Err       error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("Failed to fetch claims for publisher %v: %v", e.Publisher, e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// AdaptSource allows a Source to be used as a SourceV2.
+// Since a Source does not report failures, every item it returns is a claim and no error is ever returned.
+func AdaptSource(source Source) SourceV2 {
+	return sourceAdapter{source}
+}
+
+type sourceAdapter struct {
+	source Source
+}
+
+func (adapter sourceAdapter) FetchClaims(ctx context.Context, publisher string) (FetchResult, error) {
+	claims := adapter.source.GetClaims(ctx, publisher)
+	return FetchResult{Fetched: len(claims), Claims: claims}, nil
+}
+
+// logFailures logs the failures of a fetch, used by sources implementing Source which cannot report them
+func logFailures(publisher string, result FetchResult, err error) {
+	if err != nil {
+		log.Println(err)
+	}
+	for _, failure := range result.Failures {
+		log.Printf("%v: %v", publisher, failure)
+	}
+}
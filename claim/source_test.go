@@ -0,0 +1,29 @@
+package claim
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_AdaptSource(t *testing.T) {
+	claims := []Claim{
+		claim("title", "publisher_name", "http://article_url.com", KindNews, VerdictTrue, time.Unix(100, 0)),
+	}
+	want := FetchResult{Fetched: 1, Claims: claims}
+	got, err := AdaptSource(staticSource(claims)).FetchClaims(context.Background(), "publisher.com")
+	if err != nil {
+		t.Errorf("sourceAdapter.FetchClaims() error = %v, want nil", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sourceAdapter.FetchClaims() = %v, want %v", got, want)
+	}
+}
+
+// staticSource is a Source returning the same claims for every publisher
+type staticSource []Claim
+
+func (source staticSource) GetClaims(ctx context.Context, publisher string) []Claim {
+	return source
+}
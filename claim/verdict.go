@@ -0,0 +1,54 @@
+package claim
+
+import "fmt"
+
+// Verdict is the rating given to a claim by the publisher reviewing it
+type Verdict string
+
+const (
+	VerdictTrue        Verdict = "true"
+	VerdictMostlyTrue  Verdict = "mostly-true"
+	VerdictMixed       Verdict = "mixed"
+	VerdictMostlyFalse Verdict = "mostly-false"
+	VerdictFalse       Verdict = "false"
+	VerdictMisleading  Verdict = "misleading"
+	VerdictSatire      Verdict = "satire"
+	VerdictUnproven    Verdict = "unproven"
+)
+
+// Verdicts lists every valid verdict, from most to least truthful
+var Verdicts = []Verdict{
+	VerdictTrue,
+	VerdictMostlyTrue,
+	VerdictMixed,
+	VerdictMostlyFalse,
+	VerdictFalse,
+	VerdictMisleading,
+	VerdictSatire,
+	VerdictUnproven,
+}
+
+// ParseVerdict returns the Verdict matching the given string, or an error if it is not a valid verdict
+func ParseVerdict(s string) (Verdict, error) {
+	verdict := Verdict(s)
+	if !verdict.IsValid() {
+		return "", fmt.Errorf("Invalid verdict '%v'", s)
+	}
+	return verdict, nil
+}
+
+// IsValid returns true if the verdict is one of the known Verdicts
+func (v Verdict) IsValid() bool {
+	for _, verdict := range Verdicts {
+		if v == verdict {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFact returns true if a claim with this verdict should be considered a fact in the game.
+// Only claims rated true or mostly true are facts, anything else is considered fake.
+func (v Verdict) IsFact() bool {
+	return v == VerdictTrue || v == VerdictMostlyTrue
+}
@@ -0,0 +1,63 @@
+package claim
+
+import "testing"
+
+func Test_ParseVerdict(t *testing.T) {
+	tests := []struct {
+		name    string
+		verdict string
+		want    Verdict
+		wantErr bool
+	}{
+		{
+			name:    "parsing 'mostly-true' returns mostly-true",
+			verdict: "mostly-true",
+			want:    VerdictMostlyTrue,
+			wantErr: false,
+		},
+		{
+			name:    "parsing an empty string returns an error",
+			verdict: "",
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "parsing an unknown verdict returns an error",
+			verdict: "Mostly True",
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVerdict(tt.verdict)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseVerdict() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseVerdict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerdict_IsFact(t *testing.T) {
+	facts := map[Verdict]bool{
+		VerdictTrue:        true,
+		VerdictMostlyTrue:  true,
+		VerdictMixed:       false,
+		VerdictMostlyFalse: false,
+		VerdictFalse:       false,
+		VerdictMisleading:  false,
+		VerdictSatire:      false,
+		VerdictUnproven:    false,
+	}
+	for _, verdict := range Verdicts {
+		t.Run(string(verdict), func(t *testing.T) {
+			if got := verdict.IsFact(); got != facts[verdict] {
+				t.Errorf("Verdict(%v).IsFact() = %v, want %v", verdict, got, facts[verdict])
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package claimio
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Format is the file format claims are imported from or exported to
+type Format string
+
+const (
+	// one JSON encoded claim per line, including its reviews
+	FormatJSONL Format = "jsonl"
+	// one claim per row after a header row, without its reviews
+	FormatCSV Format = "csv"
+)
+
+// ParseFormat returns the Format matching the given string, or an error if it is not a known format
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(strings.ToLower(s)); format {
+	case FormatJSONL, FormatCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("Invalid format '%v', expected %v or %v", s, FormatJSONL, FormatCSV)
+	}
+}
+
+// FormatOfFile returns the Format matching the extension of the given path, or an error if the extension is unknown
+func FormatOfFile(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	case ".csv":
+		return FormatCSV, nil
+	default:
+		return "", fmt.Errorf("Cannot tell the format of '%v' from its extension", path)
+	}
+}
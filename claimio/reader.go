@@ -0,0 +1,209 @@
+package claimio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fake-or-fact/claim"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Reader reads claims one at a time
+type Reader interface {
+	// Read returns the next claim, or io.EOF once every claim was read.
+	// A record error is returned if the record is not a valid claim, in which case the following records can still be read.
+	Read() (claim.Claim, error)
+}
+
+// NewReader returns a Reader parsing claims in the given format.
+// Returns an error if the header of a CSV file is missing required columns.
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatJSONL:
+		return newJSONLReader(r), nil
+	case FormatCSV:
+		return newCSVReader(r)
+	default:
+		return nil, fmt.Errorf("Invalid format '%v'", format)
+	}
+}
+
+// recordError is returned when a record cannot be parsed or is not a valid claim
+type recordError struct {
+	// the line of a JSONL file or the row of a CSV file the record starts at, starting at 1
+	line int
+	err  error
+}
+
+func (e recordError) Error() string {
+	return fmt.Sprintf("Invalid record at line %v: %v", e.line, e.err)
+}
+
+func (e recordError) Unwrap() error {
+	return e.err
+}
+
+// IsRecordError returns true if the error was caused by a record which is not a valid claim
+func IsRecordError(err error) bool {
+	var recordErr recordError
+	return errors.As(err, &recordErr)
+}
+
+// the maximum length of a line in a JSONL file, which can be long due to the claim's reviews
+const maxLineBytes = 1 << 20
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	return &jsonlReader{scanner: scanner}
+}
+
+// Read decodes the next non-blank line as a claim
+func (reader *jsonlReader) Read() (claim.Claim, error) {
+	for reader.scanner.Scan() {
+		reader.line++
+		line := strings.TrimSpace(reader.scanner.Text())
+		if line == "" {
+			continue
+		}
+		record := claim.Claim{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return claim.Claim{}, recordError{reader.line, err}
+		}
+		validClaim, err := asValidClaim(record)
+		if err != nil {
+			return claim.Claim{}, recordError{reader.line, err}
+		}
+		return validClaim, nil
+	}
+	if err := reader.scanner.Err(); err != nil {
+		return claim.Claim{}, err
+	}
+	return claim.Claim{}, io.EOF
+}
+
+// the columns of a CSV file, in the order they are exported
+var csvColumns = []string{"title", "publisher_name", "url", "kind", "verdict", "reviewed_at", "claimant", "claim_date", "appearance_url"}
+
+// the columns a CSV file must contain, others are optional
+var requiredCSVColumns = csvColumns[:6]
+
+type csvReader struct {
+	reader *csv.Reader
+	// the index of every column of the header
+	columns map[string]int
+	row     int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read CSV header: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range requiredCSVColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("Missing CSV column '%v'", column)
+		}
+	}
+	return &csvReader{reader: reader, columns: columns, row: 1}, nil
+}
+
+// Read parses the next row as a claim
+func (reader *csvReader) Read() (claim.Claim, error) {
+	row, err := reader.reader.Read()
+	if err == io.EOF {
+		return claim.Claim{}, io.EOF
+	}
+	reader.row++
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return claim.Claim{}, recordError{reader.row, err}
+		}
+		return claim.Claim{}, err
+	}
+	record, err := reader.asRecord(row)
+	if err != nil {
+		return claim.Claim{}, recordError{reader.row, err}
+	}
+	validClaim, err := asValidClaim(record)
+	if err != nil {
+		return claim.Claim{}, recordError{reader.row, err}
+	}
+	return validClaim, nil
+}
+
+func (reader *csvReader) asRecord(row []string) (claim.Claim, error) {
+	reviewedAt, err := ParseTime(reader.field(row, "reviewed_at"))
+	if err != nil {
+		return claim.Claim{}, err
+	}
+	claimDate, err := ParseTime(reader.field(row, "claim_date"))
+	if err != nil {
+		return claim.Claim{}, err
+	}
+	return claim.Claim{
+		Title:         reader.field(row, "title"),
+		PublisherName: reader.field(row, "publisher_name"),
+		URL:           reader.field(row, "url"),
+		Kind:          claim.Kind(reader.field(row, "kind")),
+		Verdict:       claim.Verdict(reader.field(row, "verdict")),
+		ReviewedAt:    reviewedAt,
+		Claimant:      reader.field(row, "claimant"),
+		ClaimDate:     claimDate,
+		AppearanceURL: reader.field(row, "appearance_url"),
+	}, nil
+}
+
+// field returns the trimmed value of a column, empty if the file does not have the column
+func (reader *csvReader) field(row []string, column string) string {
+	i, ok := reader.columns[column]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// ParseTime parses an RFC 3339 time or a date, returning the zero time for an empty string
+func ParseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid time '%v', expected RFC 3339 or YYYY-MM-DD", value)
+	}
+	return t, nil
+}
+
+// asValidClaim validates a parsed record through claim.NewClaim, which also derives IsFact from the verdict,
+// and copies the optional fields NewClaim does not take
+func asValidClaim(record claim.Claim) (claim.Claim, error) {
+	validClaim, err := claim.NewClaim(record.Title, record.PublisherName, record.URL, record.Kind, record.Verdict, record.ReviewedAt)
+	if err != nil {
+		return claim.Claim{}, err
+	}
+	validClaim.Claimant = record.Claimant
+	validClaim.ClaimDate = record.ClaimDate
+	validClaim.AppearanceURL = record.AppearanceURL
+	validClaim.OriginalURL = record.OriginalURL
+	validClaim.Reviews = record.Reviews
+	return validClaim, nil
+}
@@ -0,0 +1,149 @@
+package claimio
+
+import (
+	"fake-or-fact/claim"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readAll reads every claim and the position of every record error until EOF
+func readAll(t *testing.T, reader Reader) ([]claim.Claim, []string) {
+	var claims []claim.Claim
+	var recordErrs []string
+	for {
+		c, err := reader.Read()
+		if err == io.EOF {
+			return claims, recordErrs
+		}
+		if IsRecordError(err) {
+			recordErrs = append(recordErrs, err.Error())
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Read() unexpected error = %v", err)
+		}
+		claims = append(claims, c)
+	}
+}
+
+func TestReader_Read(t *testing.T) {
+	reviewedAt := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	fact := claim.Claim{
+		Title:         "The sky is blue",
+		PublisherName: "Daily",
+		URL:           "http://daily.com/sky",
+		Kind:          claim.KindNews,
+		Verdict:       claim.VerdictTrue,
+		IsFact:        true,
+		ReviewedAt:    reviewedAt,
+	}
+	fake := claim.Claim{
+		Title:         "Moon made of cheese",
+		PublisherName: "Checker",
+		URL:           "http://checker.com/moon",
+		Kind:          claim.KindFactCheck,
+		Verdict:       claim.VerdictFalse,
+		IsFact:        false,
+		ReviewedAt:    reviewedAt,
+		Claimant:      "A farmer",
+		ClaimDate:     time.Date(2020, 4, 30, 0, 0, 0, 0, time.UTC),
+		AppearanceURL: "http://social.com/post",
+	}
+	canonicalizedFake := fake
+	canonicalizedFake.OriginalURL = "http://checker.com/moon/?utm_source=rss"
+	tests := []struct {
+		name          string
+		format        Format
+		input         string
+		wantClaims    []claim.Claim
+		wantRecordErr []string
+		wantErr       bool
+	}{
+		{
+			name:   "JSONL claims are validated and their IsFact derived from the verdict, blank lines are skipped",
+			format: FormatJSONL,
+			input: `{"Title":"the sky is blue","PublisherName":"Daily","URL":"http://daily.com/sky","Kind":"news","Verdict":"true","ReviewedAt":"2020-05-01T10:00:00Z"}
+
+{"Title":"Moon made of cheese","PublisherName":"Checker","URL":"http://checker.com/moon","Kind":"fact-check","Verdict":"false","IsFact":true,"ReviewedAt":"2020-05-01T10:00:00Z","Claimant":"A farmer","ClaimDate":"2020-04-30T00:00:00Z","AppearanceURL":"http://social.com/post","OriginalURL":"http://checker.com/moon/?utm_source=rss"}
+`,
+			wantClaims: []claim.Claim{fact, canonicalizedFake},
+		},
+		{
+			name:   "invalid JSONL records are reported with their line and do not stop the reader",
+			format: FormatJSONL,
+			input: `{"Title":"","PublisherName":"Daily","URL":"http://daily.com/empty","Kind":"news","Verdict":"true","ReviewedAt":"2020-05-01T10:00:00Z"}
+not json
+{"Title":"The sky is blue","PublisherName":"Daily","URL":"http://daily.com/sky","Kind":"unknown","Verdict":"true","ReviewedAt":"2020-05-01T10:00:00Z"}
+{"Title":"The sky is blue","PublisherName":"Daily","URL":"http://daily.com/sky","Kind":"news","Verdict":"true","ReviewedAt":"2020-05-01T10:00:00Z"}
+`,
+			wantClaims:    []claim.Claim{fact},
+			wantRecordErr: []string{"line 1", "line 2", "line 3"},
+		},
+		{
+			name:   "CSV columns are matched by header name and optional columns can be omitted",
+			format: FormatCSV,
+			input: `url,Title,publisher_name,kind,verdict,reviewed_at
+http://daily.com/sky,The sky is blue,Daily,news,true,2020-05-01T10:00:00Z
+`,
+			wantClaims: []claim.Claim{fact},
+		},
+		{
+			name:   "CSV optional columns are read and dates can omit the time",
+			format: FormatCSV,
+			input: `title,publisher_name,url,kind,verdict,reviewed_at,claimant,claim_date,appearance_url
+Moon made of cheese,Checker,http://checker.com/moon,fact-check,false,2020-05-01T10:00:00Z,A farmer,2020-04-30,http://social.com/post
+`,
+			wantClaims: []claim.Claim{fake},
+		},
+		{
+			name:   "invalid CSV rows are reported with their row and do not stop the reader",
+			format: FormatCSV,
+			input: `title,publisher_name,url,kind,verdict,reviewed_at
+The sky is blue,Daily,http://daily.com/sky,news,true,yesterday
+The sky is blue,Daily,http://daily.com/sky,news,maybe,2020-05-01T10:00:00Z
+too,few,columns
+The sky is blue,Daily,http://daily.com/sky,news,true,2020-05-01T10:00:00Z
+`,
+			wantClaims:    []claim.Claim{fact},
+			wantRecordErr: []string{"line 2", "line 3", "line 4"},
+		},
+		{
+			name:    "a CSV header missing a required column returns an error",
+			format:  FormatCSV,
+			input:   "title,publisher_name,url,kind,verdict\n",
+			wantErr: true,
+		},
+		{
+			name:    "an unknown format returns an error",
+			format:  Format("xml"),
+			input:   "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewReader(tt.format, strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			gotClaims, gotRecordErrs := readAll(t, reader)
+			if !reflect.DeepEqual(gotClaims, tt.wantClaims) {
+				t.Errorf("Read() claims = %+v, want %+v", gotClaims, tt.wantClaims)
+			}
+			if len(gotRecordErrs) != len(tt.wantRecordErr) {
+				t.Fatalf("Read() record errors = %v, want %v", gotRecordErrs, tt.wantRecordErr)
+			}
+			for i, position := range tt.wantRecordErr {
+				if !strings.Contains(gotRecordErrs[i], position) {
+					t.Errorf("Read() record error = %v, want it at %v", gotRecordErrs[i], position)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package claimio
+
+import (
+	"fake-or-fact/claim"
+	"fake-or-fact/repo"
+	"fmt"
+	"io"
+)
+
+// ImportReport is the outcome of an import
+type ImportReport struct {
+	// the number of claims which were persisted
+	Imported int
+	// the URLs of the claims which were already persisted, as read
+	Duplicates []string
+	// the reason every other record could not be imported
+	Failures []string
+}
+
+// Import persists every claim read by the reader, canonicalizing their URLs.
+// Invalid records and claims which cannot be persisted are reported instead of stopping the import,
+// an error is only returned if the reader fails to read its input.
+func Import(reader Reader, claims repo.ClaimRepo, canonicalizer claim.URLCanonicalizer) (ImportReport, error) {
+	report := ImportReport{}
+	for {
+		c, err := reader.Read()
+		if err == io.EOF {
+			return report, nil
+		}
+		if IsRecordError(err) {
+			report.Failures = append(report.Failures, err.Error())
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+
+		result, saveErr := claims.Save(canonicalizer.CanonicalizeClaim(c), repo.KeepStored)
+		switch {
+		case saveErr != nil:
+			report.Failures = append(report.Failures, fmt.Sprintf("Failed to save claim '%v': %v", c.URL, saveErr))
+		case result == repo.ClaimInserted:
+			report.Imported++
+		default:
+			report.Duplicates = append(report.Duplicates, c.URL)
+		}
+	}
+}
+
+// Export writes every stored claim matching the filter and returns the number of claims written
+func Export(claims repo.ClaimRepo, filter repo.ExportFilter, writer Writer) (int, error) {
+	exported := 0
+	err := claims.Export(filter, func(c claim.Claim) error {
+		if err := writer.Write(c); err != nil {
+			return err
+		}
+		exported++
+		return nil
+	})
+	if err != nil {
+		return exported, err
+	}
+	return exported, writer.Flush()
+}
@@ -0,0 +1,192 @@
+package claimio
+
+import (
+	"bytes"
+	"errors"
+	"fake-or-fact/claim"
+	"fake-or-fact/repo"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockClaimRepo struct {
+	saved []claim.Claim
+	// the error returned when saving the claim with the given URL
+	saveErrs map[string]error
+	stored   []claim.Claim
+	filter   repo.ExportFilter
+}
+
+func (mock *mockClaimRepo) Save(c claim.Claim, onConflict repo.OnConflict) (repo.SaveResult, error) {
+	if err := mock.saveErrs[c.URL]; err != nil {
+		return 0, err
+	}
+	mock.saved = append(mock.saved, c)
+	return repo.ClaimInserted, nil
+}
+
+func (mock *mockClaimRepo) SaveBatch(claims []claim.Claim, onConflict repo.OnConflict) ([]repo.SaveResult, error) {
+	return nil, nil
+}
+
+func (mock *mockClaimRepo) Get(query repo.ClaimQuery) ([]claim.Claim, error) {
+	return nil, nil
+}
+
+func (mock *mockClaimRepo) GetHistory(id string) (repo.ClaimHistory, error) {
+	return repo.ClaimHistory{}, nil
+}
+
+func (mock *mockClaimRepo) Export(filter repo.ExportFilter, each func(claim.Claim) error) error {
+	mock.filter = filter
+	for _, c := range mock.stored {
+		if err := each(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestImport(t *testing.T) {
+	input := `title,publisher_name,url,kind,verdict,reviewed_at
+The sky is blue,Daily,http://daily.com/sky,news,true,2020-05-01T10:00:00Z
+,Daily,http://daily.com/empty,news,true,2020-05-01T10:00:00Z
+A very long publisher name,Daily,http://daily.com/long,news,true,2020-05-01T10:00:00Z
+Moon made of cheese,Checker,http://checker.com/moon,fact-check,false,2020-05-01T10:00:00Z
+`
+	reader, err := NewReader(FormatCSV, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	mock := &mockClaimRepo{saveErrs: map[string]error{"https://daily.com/long": errors.New("value too long")}}
+	canonicalizer, _ := claim.NewURLCanonicalizer(claim.URLRules{})
+
+	report, err := Import(reader, mock, canonicalizer)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if report.Imported != 2 || len(mock.saved) != 2 {
+		t.Errorf("Import() imported = %v, saved %v claims, want 2", report.Imported, len(mock.saved))
+	}
+	if len(report.Failures) != 2 {
+		t.Fatalf("Import() failures = %v, want the invalid record and the failed save", report.Failures)
+	}
+	if !strings.Contains(report.Failures[0], "line 3") || !strings.Contains(report.Failures[1], "value too long") {
+		t.Errorf("Import() failures = %v", report.Failures)
+	}
+	if len(report.Duplicates) != 0 {
+		t.Errorf("Import() duplicates = %v, want none", report.Duplicates)
+	}
+}
+
+func TestImport_Duplicates(t *testing.T) {
+	input := `{"Title":"The sky is blue","PublisherName":"Daily","URL":"http://daily.com/sky","Kind":"news","Verdict":"true","ReviewedAt":"2020-05-01T10:00:00Z"}
+{"Title":"The sky is green","PublisherName":"Daily","URL":"https://daily.com/sky/?utm_source=rss","Kind":"news","Verdict":"false","ReviewedAt":"2020-05-02T10:00:00Z"}
+`
+	reader, _ := NewReader(FormatJSONL, strings.NewReader(input))
+	canonicalizer, _ := claim.NewURLCanonicalizer(claim.URLRules{})
+
+	report, err := Import(reader, repo.NewMemoryClaimRepo(), canonicalizer)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if report.Imported != 1 || !reflect.DeepEqual(report.Duplicates, []string{"https://daily.com/sky/?utm_source=rss"}) || len(report.Failures) != 0 {
+		t.Errorf("Import() = %+v, want 1 imported claim and 1 duplicate", report)
+	}
+}
+
+func TestExport(t *testing.T) {
+	reviewedAt := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	stored := []claim.Claim{
+		{
+			Title:         "Moon made of cheese",
+			PublisherName: "Checker",
+			URL:           "http://checker.com/moon",
+			Kind:          claim.KindFactCheck,
+			Verdict:       claim.VerdictFalse,
+			ReviewedAt:    reviewedAt,
+			Claimant:      "A farmer, allegedly",
+			Reviews: []claim.Review{
+				{PublisherName: "Checker", URL: "http://checker.com/moon", TextualRating: "False", Verdict: claim.VerdictFalse, ReviewedAt: reviewedAt},
+			},
+		},
+		{
+			Title:         "The sky is blue",
+			PublisherName: "Daily",
+			URL:           "http://daily.com/sky",
+			Kind:          claim.KindNews,
+			Verdict:       claim.VerdictTrue,
+			IsFact:        true,
+			ReviewedAt:    reviewedAt,
+		},
+	}
+	tests := []struct {
+		name   string
+		format Format
+		// the claims read back from the export
+		want []claim.Claim
+	}{
+		{
+			name:   "exported JSONL claims are read back along with their reviews",
+			format: FormatJSONL,
+			want:   stored,
+		},
+		{
+			name:   "exported CSV claims are read back without their reviews",
+			format: FormatCSV,
+			want:   []claim.Claim{withoutReviews(stored[0]), stored[1]},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockClaimRepo{stored: stored}
+			filter := repo.ExportFilter{Kinds: []claim.Kind{claim.KindNews}}
+			output := new(bytes.Buffer)
+			writer, err := NewWriter(tt.format, output)
+			if err != nil {
+				t.Fatalf("NewWriter() error = %v", err)
+			}
+
+			exported, err := Export(mock, filter, writer)
+			if err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			if exported != len(stored) {
+				t.Errorf("Export() = %v, want %v", exported, len(stored))
+			}
+			if !reflect.DeepEqual(mock.filter, filter) {
+				t.Errorf("Export() filter = %+v, want %+v", mock.filter, filter)
+			}
+
+			reader, err := NewReader(tt.format, output)
+			if err != nil {
+				t.Fatalf("NewReader() error = %v", err)
+			}
+			got, recordErrs := readAll(t, reader)
+			if len(recordErrs) > 0 {
+				t.Fatalf("Read() record errors = %v", recordErrs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("exported claims = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExport_Empty(t *testing.T) {
+	output := new(bytes.Buffer)
+	writer, _ := NewWriter(FormatCSV, output)
+	if _, err := Export(&mockClaimRepo{}, repo.ExportFilter{}, writer); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if got := output.String(); got != strings.Join(csvColumns, ",")+"\n" {
+		t.Errorf("Export() = %q, want only the header", got)
+	}
+}
+
+func withoutReviews(c claim.Claim) claim.Claim {
+	c.Reviews = nil
+	return c
+}
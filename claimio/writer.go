@@ -0,0 +1,88 @@
+package claimio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fake-or-fact/claim"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Writer writes claims one at a time
+type Writer interface {
+	Write(c claim.Claim) error
+	// Flush writes any buffered data and returns the first error encountered while writing, if any
+	Flush() error
+}
+
+// NewWriter returns a Writer encoding claims in the given format
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatJSONL:
+		return newJSONLWriter(w), nil
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	default:
+		return nil, fmt.Errorf("Invalid format '%v'", format)
+	}
+}
+
+type jsonlWriter struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	buffer := bufio.NewWriter(w)
+	return &jsonlWriter{buffer: buffer, encoder: json.NewEncoder(buffer)}
+}
+
+// Write encodes the claim as a single line of JSON
+func (writer *jsonlWriter) Write(c claim.Claim) error {
+	return writer.encoder.Encode(c)
+}
+
+func (writer *jsonlWriter) Flush() error {
+	return writer.buffer.Flush()
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+// newCSVWriter returns a csvWriter which writes the header row before any claim, so that an empty export is still a valid file
+func newCSVWriter(w io.Writer) *csvWriter {
+	writer := csv.NewWriter(w)
+	writer.Write(csvColumns)
+	return &csvWriter{writer}
+}
+
+// Write writes the claim as a row of the columns in csvColumns, its reviews are not written
+func (writer *csvWriter) Write(c claim.Claim) error {
+	return writer.writer.Write([]string{
+		c.Title,
+		c.PublisherName,
+		c.URL,
+		string(c.Kind),
+		string(c.Verdict),
+		formatTime(c.ReviewedAt),
+		c.Claimant,
+		formatTime(c.ClaimDate),
+		c.AppearanceURL,
+	})
+}
+
+func (writer *csvWriter) Flush() error {
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
+
+// formatTime formats a time as RFC 3339, returning an empty string for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fake-or-fact/claim"
+	"fake-or-fact/claimio"
+	"fake-or-fact/repo"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// runImport persists the claims of the files passed as arguments, "-" reading from the standard input.
+// URLs are canonicalized with the configured URL rules. Invalid records and duplicates are reported without stopping the import.
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	formatFlag := flags.String("format", "", "format of the files, jsonl or csv. Derived from the file extensions if empty")
+	configPath := configFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("Usage: fake-or-fact import [-format jsonl|csv] FILE...")
+	}
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	canonicalizer, err := claim.NewURLCanonicalizer(config.URLRules)
+	if err != nil {
+		return err
+	}
+	db, err := openDatabase(context.Background(), config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := repo.Migrate(db); err != nil {
+		return fmt.Errorf("Failed to migrate database: %v", err)
+	}
+	claims := repo.NewClaimRepo(db)
+
+	for _, path := range flags.Args() {
+		format, err := fileFormat(path, *formatFlag)
+		if err != nil {
+			return err
+		}
+		report, err := importFile(path, format, claims, canonicalizer)
+		if err != nil {
+			return fmt.Errorf("Failed to import %v: %v", path, err)
+		}
+		for _, duplicate := range report.Duplicates {
+			log.Printf("%v: claim already exists: %v", path, duplicate)
+		}
+		for _, failure := range report.Failures {
+			log.Printf("%v: %v", path, failure)
+		}
+		log.Printf("%v: imported %v claims, %v duplicates, %v failures", path, report.Imported, len(report.Duplicates), len(report.Failures))
+	}
+	return nil
+}
+
+func importFile(path string, format claimio.Format, claims repo.ClaimRepo, canonicalizer claim.URLCanonicalizer) (claimio.ImportReport, error) {
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return claimio.ImportReport{}, err
+		}
+		defer file.Close()
+		input = file
+	}
+	reader, err := claimio.NewReader(format, input)
+	if err != nil {
+		return claimio.ImportReport{}, err
+	}
+	return claimio.Import(reader, claims, canonicalizer)
+}
+
+// runExport writes the stored claims matching the filter flags to a file or the standard output
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatFlag := flags.String("format", "", "format of the output, jsonl or csv. Derived from the output file extension if empty, jsonl for the standard output")
+	output := flags.String("o", "-", "file to write the claims to, - for the standard output")
+	kinds := flags.String("kind", "", "comma-separated kinds of the exported claims, e.g. fact-check,satire")
+	publishers := flags.String("publisher", "", "comma-separated publisher names of the exported claims")
+	isFact := flags.String("fact", "", "true to only export facts, false to only export fakes")
+	after := flags.String("after", "", "only export claims reviewed at or after this time, RFC 3339 or YYYY-MM-DD")
+	before := flags.String("before", "", "only export claims reviewed before this time, RFC 3339 or YYYY-MM-DD")
+	configPath := configFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	format := claimio.FormatJSONL
+	if *formatFlag != "" || *output != "-" {
+		var err error
+		if format, err = fileFormat(*output, *formatFlag); err != nil {
+			return err
+		}
+	}
+	filter, err := parseExportFilter(*kinds, *publishers, *isFact, *after, *before)
+	if err != nil {
+		return err
+	}
+
+	db, err := openConfiguredDatabase(*configPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	writer, err := claimio.NewWriter(format, out)
+	if err != nil {
+		return err
+	}
+	exported, err := claimio.Export(repo.NewClaimRepo(db), filter, writer)
+	if err != nil {
+		return fmt.Errorf("Failed to export claims: %v", err)
+	}
+	log.Printf("Exported %v claims", exported)
+	return nil
+}
+
+// fileFormat returns the format given by the flag, or the format matching the file's extension if the flag is empty
+func fileFormat(path string, formatFlag string) (claimio.Format, error) {
+	if formatFlag != "" {
+		return claimio.ParseFormat(formatFlag)
+	}
+	return claimio.FormatOfFile(path)
+}
+
+// parseExportFilter builds the filter of an export from the values of its flags, empty values do not filter
+func parseExportFilter(kinds string, publishers string, isFact string, after string, before string) (repo.ExportFilter, error) {
+	filter := repo.ExportFilter{Publishers: splitList(publishers)}
+	for _, value := range splitList(kinds) {
+		kind, err := claim.ParseKind(value)
+		if err != nil {
+			return repo.ExportFilter{}, err
+		}
+		filter.Kinds = append(filter.Kinds, kind)
+	}
+	if isFact != "" {
+		fact, err := strconv.ParseBool(isFact)
+		if err != nil {
+			return repo.ExportFilter{}, fmt.Errorf("Invalid fact filter '%v', expected true or false", isFact)
+		}
+		filter.IsFact = &fact
+	}
+	var err error
+	if filter.ReviewedAfter, err = claimio.ParseTime(after); err != nil {
+		return repo.ExportFilter{}, err
+	}
+	if filter.ReviewedBefore, err = claimio.ParseTime(before); err != nil {
+		return repo.ExportFilter{}, err
+	}
+	return filter, nil
+}
+
+// splitList splits a comma-separated list, ignoring blank items
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fake-or-fact/claim"
+	"fake-or-fact/repo"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parseExportFilter(t *testing.T) {
+	fake := false
+	tests := []struct {
+		name       string
+		kinds      string
+		publishers string
+		isFact     string
+		after      string
+		before     string
+		want       repo.ExportFilter
+		wantErr    bool
+	}{
+		{
+			name: "empty flags do not filter",
+			want: repo.ExportFilter{},
+		},
+		{
+			name:       "every flag is parsed",
+			kinds:      "fact-check, satire",
+			publishers: "Snopes,,The Onion",
+			isFact:     "false",
+			after:      "2020-01-01",
+			before:     "2020-02-01T12:00:00Z",
+			want: repo.ExportFilter{
+				IsFact:         &fake,
+				Kinds:          []claim.Kind{claim.KindFactCheck, claim.KindSatire},
+				Publishers:     []string{"Snopes", "The Onion"},
+				ReviewedAfter:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				ReviewedBefore: time.Date(2020, 2, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "an unknown kind returns an error",
+			kinds:   "news,rumor",
+			wantErr: true,
+		},
+		{
+			name:    "an invalid fact flag returns an error",
+			isFact:  "fake",
+			wantErr: true,
+		},
+		{
+			name:    "an invalid time returns an error",
+			before:  "01/02/2020",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseExportFilter(tt.kinds, tt.publishers, tt.isFact, tt.after, tt.before)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseExportFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseExportFilter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,61 +1,504 @@
 package collector
 
 import (
+	"context"
+	"encoding/json"
 	"fake-or-fact/claim"
+	"fake-or-fact/config"
 	"fake-or-fact/repo"
+	"fmt"
 	"log"
 	"sync"
+	"time"
 )
 
+// ClaimConfig is the configuration of the fake-or-fact binary, read from claim_config.json.
+// Fields with an env tag can be overridden by the environment variable it names.
 type ClaimConfig struct {
 	Database struct {
-		Dialect          string
-		ConnectionString string
+		// the gorm dialect of the database, "postgres" or "sqlite3". Defaults to "postgres"
+		Dialect          string        `env:"FOF_DATABASE_DIALECT" default:"postgres"`
+		ConnectionString config.Secret `env:"FOF_DATABASE_CONNECTION_STRING"`
+		// the number of times connecting to the database is attempted at startup, with an increasing delay between attempts. Defaults to 5
+		ConnectAttempts int `env:"FOF_DATABASE_CONNECT_ATTEMPTS" default:"5"`
+		// the number of collected claims saved per transaction. Defaults to 100
+		SaveBatchSize int `env:"FOF_DATABASE_SAVE_BATCH_SIZE" default:"100"`
 	}
-	GoogleFactCheckAPIKey     string
-	GoogleFactCheckPublishers []string
-	RealRssFeeds              []string
-	FakeRssFeeds              []string
+	GoogleFactCheckAPIKey     config.Secret `env:"FOF_GOOGLE_API_KEY"`
+	GoogleFactCheckPublishers []GooglePublisher
+	// feeds of real news headlines, collected as facts
+	RealRssFeeds []RssFeed
+	// feeds of satire headlines, collected as fakes
+	FakeRssFeeds []RssFeed
+	// listing pages of fact-checking sites which have neither a feed nor an API
+	ScrapeSites []ScrapeSite
+	// sitemaps or article lists of fact-checking sites embedding schema.org ClaimReview JSON-LD in their articles
+	JSONLDSites []JSONLDSite
+	// rules used to classify textual ratings, evaluated in order before the default rating rules
+	RatingRules []claim.RatingRule
+	// rules used to canonicalize the URLs of collected claims, so that the same article collected with different URLs is saved once
+	URLRules claim.URLRules
+	// the interval between two collection runs of groups of sources without a schedule, e.g. "15h". Defaults to DefaultCollectionInterval if empty
+	CollectionInterval string `env:"FOF_COLLECTION_INTERVAL"`
+	// the schedule of each group of sources, keyed by group name ("google", "fake-rss", "real-rss", "scrape" or "jsonld")
+	Schedules map[string]SourceSchedule
+	// the bearer token required by admin endpoints, which are disabled if empty
+	AdminToken config.Secret `env:"FOF_ADMIN_TOKEN"`
+	// the number of consecutive runs in which a publisher must error or return nothing to be reported as unhealthy
+	UnhealthyRunThreshold int `env:"FOF_UNHEALTHY_RUN_THRESHOLD"`
+	// whether stored claims are revised when a collected claim with the same URL has another title, verdict or review date,
+	// e.g. when a publisher re-rates its review, keeping their previous values. Stored claims are left unchanged otherwise
+	UpdateRevisedClaims bool `env:"FOF_UPDATE_REVISED_CLAIMS"`
+}
+
+// DefaultCollectionInterval is the interval between collection runs used if none is configured
+const DefaultCollectionInterval = 15 * time.Hour
+
+// Interval returns the configured CollectionInterval, or DefaultCollectionInterval if none is configured
+func (config ClaimConfig) Interval() (time.Duration, error) {
+	if config.CollectionInterval == "" {
+		return DefaultCollectionInterval, nil
+	}
+	interval, err := time.ParseDuration(config.CollectionInterval)
+	if err != nil {
+		return 0, err
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("Collection interval must be positive: %v", config.CollectionInterval)
+	}
+	return interval, nil
+}
+
+// GooglePublisher is a publisher site queried through the google fact-check API, along with its query settings.
+// Settings left empty fall back to claim.DefaultGoogleQuery. A publisher can also be configured as a plain site string.
+type GooglePublisher struct {
+	Site string
+	claim.GoogleQuery
+}
+
+func (publisher *GooglePublisher) UnmarshalJSON(data []byte) error {
+	var site string
+	if err := json.Unmarshal(data, &site); err == nil {
+		*publisher = GooglePublisher{Site: site, GoogleQuery: claim.DefaultGoogleQuery()}
+		return nil
+	}
+	// the alias type prevents UnmarshalJSON from being called recursively
+	type googlePublisherFields GooglePublisher
+	fields := googlePublisherFields{GoogleQuery: claim.DefaultGoogleQuery()}
+	if err := config.UnmarshalStrict(data, &fields); err != nil {
+		return err
+	}
+	*publisher = GooglePublisher(fields)
+	return nil
+}
+
+// RssFeed is an RSS feed along with the publisher its claims are attributed to.
+// A feed can also be configured as a plain URL string, in which case the publisher is named after the feed's first category or title.
+type RssFeed struct {
+	URL string
+	claim.Publisher
+}
+
+func (feed *RssFeed) UnmarshalJSON(data []byte) error {
+	var feedURL string
+	if err := json.Unmarshal(data, &feedURL); err == nil {
+		*feed = RssFeed{URL: feedURL}
+		return nil
+	}
+	// the alias type prevents UnmarshalJSON from being called recursively
+	type rssFeedFields RssFeed
+	var fields rssFeedFields
+	if err := config.UnmarshalStrict(data, &fields); err != nil {
+		return err
+	}
+	*feed = RssFeed(fields)
+	return nil
+}
+
+// ScrapeSite is the listing page of a fact-checking site, along with how its claim reviews are scraped
+type ScrapeSite struct {
+	URL string
+	claim.ScrapeConfig
+}
+
+// JSONLDSite is the sitemap or article list of a fact-checking site embedding ClaimReview JSON-LD, along with how its articles are found
+type JSONLDSite struct {
+	URL string
+	claim.JSONLDConfig
+}
+
+// Publishers returns the publishers of the configured RSS feeds and crawled sites, excluding feeds configured as plain URLs
+// and JSON-LD sites whose publisher has no name.
+// Returns an error if a publisher or a scrape configuration is invalid.
+func (config ClaimConfig) Publishers() ([]claim.Publisher, error) {
+	publishers := make([]claim.Publisher, 0)
+	for _, feed := range append(append([]RssFeed{}, config.RealRssFeeds...), config.FakeRssFeeds...) {
+		if feed.Name == "" {
+			continue
+		}
+		if err := feed.Publisher.Validate(); err != nil {
+			return nil, fmt.Errorf("Invalid publisher for feed %v: %v", feed.URL, err)
+		}
+		publishers = append(publishers, feed.Publisher)
+	}
+	for _, site := range config.ScrapeSites {
+		if err := site.Validate(); err != nil {
+			return nil, fmt.Errorf("Invalid scrape configuration for %v: %v", site.URL, err)
+		}
+		publishers = append(publishers, site.Publisher)
+	}
+	for _, site := range config.JSONLDSites {
+		if site.Publisher.Name == "" {
+			continue
+		}
+		if err := site.Publisher.Validate(); err != nil {
+			return nil, fmt.Errorf("Invalid publisher for %v: %v", site.URL, err)
+		}
+		publishers = append(publishers, site.Publisher)
+	}
+	return publishers, nil
+}
+
+// Validate returns an error describing the first invalid setting, if any:
+// the database, collection interval, publishers, scrape configurations, rating rules, URL rules or schedules
+func (config ClaimConfig) Validate() error {
+	if config.Database.Dialect != "postgres" && config.Database.Dialect != "sqlite3" {
+		return fmt.Errorf("Unsupported Database.Dialect '%v', expected postgres or sqlite3", config.Database.Dialect)
+	}
+	if config.Database.ConnectionString == "" {
+		return fmt.Errorf("Missing Database.ConnectionString, which can also be set through FOF_DATABASE_CONNECTION_STRING")
+	}
+	if config.Database.ConnectAttempts < 0 {
+		return fmt.Errorf("Database.ConnectAttempts must not be negative: %v", config.Database.ConnectAttempts)
+	}
+	if config.Database.SaveBatchSize < 0 {
+		return fmt.Errorf("Database.SaveBatchSize must not be negative: %v", config.Database.SaveBatchSize)
+	}
+	if config.UnhealthyRunThreshold < 0 {
+		return fmt.Errorf("UnhealthyRunThreshold must not be negative: %v", config.UnhealthyRunThreshold)
+	}
+	if _, err := config.Interval(); err != nil {
+		return fmt.Errorf("Invalid collection interval: %v", err)
+	}
+	if _, err := config.Publishers(); err != nil {
+		return err
+	}
+	if _, err := claim.NewRuleBasedClassifier(config.RatingRules); err != nil {
+		return err
+	}
+	if _, err := claim.NewURLCanonicalizer(config.URLRules); err != nil {
+		return err
+	}
+	if _, err := newScheduler(nil, &config, realClock{}, nil); err != nil {
+		return err
+	}
+	return nil
 }
 
 type ClaimCollector struct {
-	r      repo.ClaimRepo
+	r    repo.ClaimRepo
+	runs repo.RunRepo
+	// stores the validators of RSS feeds, feeds are downloaded on every run if nil
+	feeds  claim.FeedCache
 	config *ClaimConfig
 }
 
-func NewClaimCollector(r repo.ClaimRepo, config *ClaimConfig) ClaimCollector {
-	return ClaimCollector{r, config}
+func NewClaimCollector(r repo.ClaimRepo, runs repo.RunRepo, feeds claim.FeedCache, config *ClaimConfig) ClaimCollector {
+	return ClaimCollector{r, runs, feeds, config}
+}
+
+// names of the groups of sources claims are collected from
+const (
+	googleSourceName  = "google"
+	fakeRssSourceName = "fake-rss"
+	realRssSourceName = "real-rss"
+	scrapeSourceName  = "scrape"
+	jsonldSourceName  = "jsonld"
+)
+
+// PublisherOutcome summarizes the collection of a publisher's claims
+type PublisherOutcome struct {
+	// the group of sources the publisher belongs to, e.g. "google"
+	Source    string
+	Publisher string
+	// the number of items returned by the publisher
+	Fetched int
+	// the number of items which could be converted to claims
+	Parsed int
+	// the number of items which could not be converted to claims
+	Rejected int
+	// the number of claims which were not persisted since they reference visual media
+	VisualFiltered int
+	// true if the publisher reported that nothing changed since it was last fetched
+	NotModified bool
+	// the number of claims which were persisted
+	New int
+	// the number of claims which were already persisted
+	Duplicate int
+	// the error encountered while fetching the publisher's claims, nil on success
+	Err error
+}
+
+func (outcome PublisherOutcome) String() string {
+	summary := fmt.Sprintf("Publisher %v (%v): fetched %v, parsed %v, rejected %v, visual %v, new %v, duplicate %v",
+		outcome.Publisher, outcome.Source, outcome.Fetched, outcome.Parsed, outcome.Rejected, outcome.VisualFiltered, outcome.New, outcome.Duplicate)
+	if outcome.NotModified {
+		summary += ", not modified"
+	}
+	if outcome.Err != nil {
+		summary += fmt.Sprintf(", failed: %v", outcome.Err)
+	}
+	return summary
+}
+
+// returns a new PublisherRun based on a PublisherOutcome.
+func (outcome PublisherOutcome) asPublisherRun() repo.PublisherRun {
+	errMessage := ""
+	if outcome.Err != nil {
+		errMessage = outcome.Err.Error()
+	}
+	return repo.PublisherRun{
+		Source:         outcome.Source,
+		Publisher:      outcome.Publisher,
+		Fetched:        outcome.Fetched,
+		Parsed:         outcome.Parsed,
+		Rejected:       outcome.Rejected,
+		VisualFiltered: outcome.VisualFiltered,
+		NotModified:    outcome.NotModified,
+		New:            outcome.New,
+		Duplicate:      outcome.Duplicate,
+		Error:          errMessage,
+	}
+}
+
+// CollectAndPersist retrieves claims from the given groups of sources (or every group if none is given) and persists the ones which are not stored yet.
+// Cancelling the context stops the collection once the publishers being queried are done.
+// The run is recorded along with the outcome of every publisher which was queried, and the outcomes are returned.
+func (collector ClaimCollector) CollectAndPersist(ctx context.Context, groups ...string) []PublisherOutcome {
+	if len(groups) == 0 {
+		groups = SourceGroups()
+	}
+	return collector.collectAndPersist(ctx, newProgressRecorder(groups), groups)
+}
+
+// sourceGroup is a group of publishers whose claims are retrieved from the same source
+type sourceGroup struct {
+	name       string
+	source     claim.SourceV2
+	publishers []string
 }
 
-func (collector ClaimCollector) CollectAndPersist() {
+// sourceGroups returns every configured group of sources, using the given classifier for textual ratings
+func (collector ClaimCollector) sourceGroups(classifier claim.RatingClassifier) []sourceGroup {
 	config := collector.config
+	googleQueries := make(map[string]claim.GoogleQuery)
+	googleSites := make([]string, 0, len(config.GoogleFactCheckPublishers))
+	for _, publisher := range config.GoogleFactCheckPublishers {
+		googleQueries[publisher.Site] = publisher.GoogleQuery
+		googleSites = append(googleSites, publisher.Site)
+	}
+	scrapeConfigs := make(map[string]claim.ScrapeConfig)
+	scrapeURLs := make([]string, 0, len(config.ScrapeSites))
+	for _, site := range config.ScrapeSites {
+		scrapeConfigs[site.URL] = site.ScrapeConfig
+		scrapeURLs = append(scrapeURLs, site.URL)
+	}
+	jsonldConfigs := make(map[string]claim.JSONLDConfig)
+	jsonldURLs := make([]string, 0, len(config.JSONLDSites))
+	for _, site := range config.JSONLDSites {
+		jsonldConfigs[site.URL] = site.JSONLDConfig
+		jsonldURLs = append(jsonldURLs, site.URL)
+	}
+	fakeFeedPublishers, fakeFeedURLs := rssFeedPublishers(config.FakeRssFeeds)
+	realFeedPublishers, realFeedURLs := rssFeedPublishers(config.RealRssFeeds)
+	return []sourceGroup{
+		{googleSourceName, claim.NewGoogleSource(config.GoogleFactCheckAPIKey.Value(), classifier, googleQueries), googleSites},
+		{fakeRssSourceName, claim.NewRssSource(claim.KindSatire, collector.feeds, fakeFeedPublishers), fakeFeedURLs},
+		{realRssSourceName, claim.NewRssSource(claim.KindNews, collector.feeds, realFeedPublishers), realFeedURLs},
+		{scrapeSourceName, claim.NewScrapeSource(classifier, scrapeConfigs), scrapeURLs},
+		{jsonldSourceName, claim.NewJSONLDSource(classifier, jsonldConfigs), jsonldURLs},
+	}
+}
+
+// rssFeedPublishers returns the publishers of the feeds keyed by feed URL, along with the feed URLs
+func rssFeedPublishers(feeds []RssFeed) (map[string]claim.Publisher, []string) {
+	publishers := make(map[string]claim.Publisher)
+	feedURLs := make([]string, 0, len(feeds))
+	for _, feed := range feeds {
+		publishers[feed.URL] = feed.Publisher
+		feedURLs = append(feedURLs, feed.URL)
+	}
+	return publishers, feedURLs
+}
+
+// SourceGroups returns the names of every group of sources claims can be collected from
+func SourceGroups() []string {
+	return []string{googleSourceName, fakeRssSourceName, realRssSourceName, scrapeSourceName, jsonldSourceName}
+}
+
+// collectAndPersist is CollectAndPersist, reporting the progress of the run through the given recorder
+func (collector ClaimCollector) collectAndPersist(ctx context.Context, progress *progressRecorder, groupNames []string) []PublisherOutcome {
+	config := collector.config
+
+	ratingRules := append(append([]claim.RatingRule{}, config.RatingRules...), claim.DefaultRatingRules()...)
+	classifier, classifierErr := claim.NewRuleBasedClassifier(ratingRules)
+	if classifierErr != nil {
+		log.Printf("Cannot collect claims with invalid rating rules: %v", classifierErr)
+		progress.finish()
+		return nil
+	}
+	canonicalizer, canonicalizerErr := claim.NewURLCanonicalizer(config.URLRules)
+	if canonicalizerErr != nil {
+		log.Printf("Cannot collect claims with invalid URL rules: %v", canonicalizerErr)
+		progress.finish()
+		return nil
+	}
 
-	googleSource := claim.NewGoogleSource(config.GoogleFactCheckAPIKey)
-	googleClaimSink := goThroughClaims(googleSource, config.GoogleFactCheckPublishers)
+	groups := make([]sourceGroup, 0)
+	publisherCount := 0
+	for _, group := range collector.sourceGroups(classifier) {
+		if len(groupNames) == 0 || contains(groupNames, group.name) {
+			groups = append(groups, group)
+			publisherCount += len(group.publishers)
+		}
+	}
+	progress.begin(publisherCount)
 
-	fakeRssSource := claim.NewRssSource(false)
-	fakeRssClaimSink := goThroughClaims(fakeRssSource, config.FakeRssFeeds)
+	sinks := make([]<-chan collectedClaim, 0, len(groups))
+	for _, group := range groups {
+		sinks = append(sinks, goThroughClaims(ctx, group, config.Schedules[group.name].concurrency(), progress))
+	}
+	collector.persist(aggregateClaimChannels(sinks...), canonicalizer, progress)
 
-	realRssSource := claim.NewRssSource(true)
-	realRssClaimSink := goThroughClaims(realRssSource, config.RealRssFeeds)
+	for _, unmatched := range classifier.UnmatchedRatings() {
+		log.Printf("Unmatched textual rating '%v' (site: %v, language: %v): %v occurrences", unmatched.TextualRating, unmatched.Site, unmatched.LanguageCode, unmatched.Count)
+	}
 
-	aggregatedClaimChannel := aggregateClaimChannels(googleClaimSink, fakeRssClaimSink, realRssClaimSink)
-	for claim := range aggregatedClaimChannel {
+	progress.finish()
+	snapshot := progress.snapshot()
+	run := repo.CollectionRun{StartedAt: snapshot.StartedAt, FinishedAt: snapshot.FinishedAt}
+	for _, outcome := range snapshot.outcomes {
+		log.Println(outcome)
+		run.Publishers = append(run.Publishers, outcome.asPublisherRun())
+	}
+	if err := collector.runs.Save(run); err != nil {
+		log.Printf("Failed to record collection run: %v", err)
+	}
+	return snapshot.outcomes
+}
+
+// persist saves the claims pushed into the channel until it is closed, counting new and duplicate claims in their outcomes.
+// The URLs of claims are canonicalized before they are saved, so that claims are duplicates if their canonical URLs are equal.
+// Stored claims whose title, verdict or review date changed are revised if UpdateRevisedClaims is set.
+// Claims are saved in transactions of Database.SaveBatchSize claims.
+// The validators of a feed are stored once all of its claims were saved, and not at all if one of them could not be saved.
+func (collector ClaimCollector) persist(claims <-chan collectedClaim, canonicalizer claim.URLCanonicalizer, progress *progressRecorder) {
+	batchSize := collector.config.Database.SaveBatchSize
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	onConflict := repo.KeepStored
+	if collector.config.UpdateRevisedClaims {
+		onConflict = repo.UpdateStored
+	}
+	batch := make([]collectedClaim, 0, batchSize)
+	// feeds whose claims were all pushed, saved along with the batch holding their last claims
+	pending := make([]collectedClaim, 0)
+	failed := make(map[*PublisherOutcome]bool)
+	flush := func() {
+		for _, outcome := range collector.saveBatch(batch, onConflict, progress) {
+			failed[outcome] = true
+		}
+		batch = batch[:0]
+		for _, feed := range pending {
+			collector.saveValidators(feed, failed[feed.outcome])
+		}
+		pending = pending[:0]
+	}
+	for collected := range claims {
+		if collected.validators != nil {
+			pending = append(pending, collected)
+			continue
+		}
+		collected.claim = canonicalizer.CanonicalizeClaim(collected.claim)
+		if batch = append(batch, collected); len(batch) == batchSize {
+			flush()
+		}
+	}
+	flush()
+}
 
-		e := collector.r.Save(claim)
-		if e != nil && !repo.IsClaimExistsError(e) {
-			log.Println(e)
+// saveBatch saves the claims in a single transaction. If the transaction fails, every claim is saved on its own
+// so that a single invalid claim does not prevent the others from being saved.
+// Returns the outcomes of the publishers whose claims could not be saved.
+func (collector ClaimCollector) saveBatch(batch []collectedClaim, onConflict repo.OnConflict, progress *progressRecorder) []*PublisherOutcome {
+	if len(batch) == 0 {
+		return nil
+	}
+	claims := make([]claim.Claim, 0, len(batch))
+	for _, collected := range batch {
+		claims = append(claims, collected.claim)
+	}
+	results, err := collector.r.SaveBatch(claims, onConflict)
+	if err == nil {
+		for i, result := range results {
+			countSaved(batch[i], result, progress)
+		}
+		return nil
+	}
+	log.Printf("Failed to save a batch of %v claims, saving them one by one: %v", len(batch), err)
+	failed := make([]*PublisherOutcome, 0)
+	for _, collected := range batch {
+		result, err := collector.r.Save(collected.claim, onConflict)
+		if err != nil {
+			log.Println(err)
+			failed = append(failed, collected.outcome)
+			continue
 		}
+		countSaved(collected, result, progress)
+	}
+	return failed
+}
+
+// saveValidators stores the validators of a feed unless some of its claims could not be saved,
+// in which case the feed is downloaded again on the next run
+func (collector ClaimCollector) saveValidators(feed collectedClaim, failed bool) {
+	if collector.feeds == nil {
+		return
+	}
+	feedURL := feed.outcome.Publisher
+	if failed {
+		log.Printf("Not caching validators of feed %v since some of its claims could not be saved", feedURL)
+		return
+	}
+	if err := collector.feeds.SaveValidators(feedURL, *feed.validators); err != nil {
+		log.Printf("Failed to cache validators of feed %v: %v", feedURL, err)
+	}
+}
+
+// countSaved counts a saved claim in the outcome of its publisher, revised claims being counted as duplicates
+func countSaved(collected collectedClaim, result repo.SaveResult, progress *progressRecorder) {
+	if result == repo.ClaimInserted {
+		progress.update(collected.outcome, func(outcome *PublisherOutcome) { outcome.New++ })
+	} else {
+		progress.update(collected.outcome, func(outcome *PublisherOutcome) { outcome.Duplicate++ })
 	}
+}
 
+// collectedClaim is a claim pushed into a sink, along with the outcome of the publisher it was collected from.
+// Once all claims of a feed were pushed, an item without claim holding the validators of the feed is pushed.
+type collectedClaim struct {
+	claim      claim.Claim
+	outcome    *PublisherOutcome
+	validators *claim.FeedValidators
 }
 
-func aggregateClaimChannels(chans ...<-chan claim.Claim) <-chan claim.Claim {
+func aggregateClaimChannels(chans ...<-chan collectedClaim) <-chan collectedClaim {
 	var aggregateWaitGroup sync.WaitGroup
-	aggregateChannel := make(chan claim.Claim)
+	aggregateChannel := make(chan collectedClaim)
 	for _, c := range chans {
 		aggregateWaitGroup.Add(1)
-		go func(c <-chan claim.Claim) {
+		go func(c <-chan collectedClaim) {
 			for claim := range c {
 				aggregateChannel <- claim
 			}
@@ -69,29 +512,74 @@ func aggregateClaimChannels(chans ...<-chan claim.Claim) <-chan claim.Claim {
 	return aggregateChannel
 }
 
-// Asynchronously retrieves valid claims for the specified publishers and pushes them into the returned channel (sink).
+// Asynchronously retrieves valid claims for the publishers of the group and pushes them into the returned channel (sink).
+// At most concurrency publishers are queried at the same time.
 // The channel WILL BE CLOSED once all claims have been collected.
 // Only claims that could be correctly parsed and which do not reference any visuals are pushed into the channel.
-func goThroughClaims(source claim.Source, publishers []string) <-chan claim.Claim {
-	sink := make(chan claim.Claim)
+// The outcome of every publisher is recorded in progress before its claims are pushed. Publishers are no longer queried once the context is done.
+func goThroughClaims(ctx context.Context, group sourceGroup, concurrency int, progress *progressRecorder) <-chan collectedClaim {
+	sink := make(chan collectedClaim)
+	publishers := make(chan string)
+	var workersWaitGroup sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		workersWaitGroup.Add(1)
+		go func() {
+			for publisher := range publishers {
+				goThroughPublisher(ctx, group.name, group.source, publisher, progress, sink)
+			}
+			workersWaitGroup.Done()
+		}()
+	}
 	go func() {
-		for _, publisher := range publishers {
-			validClaims := source.GetClaims(publisher)
-			facts := 0
-			fakes := 0
-			for _, c := range validClaims {
-				if c.IsFact {
-					facts++
-				} else {
-					fakes++
-				}
-				if !c.ReferencesVisuals() {
-					sink <- c
-				}
+		for _, publisher := range group.publishers {
+			if ctx.Err() != nil {
+				break
 			}
-			log.Printf("Publisher %v: %v facts and %v fakes", publisher, facts, fakes)
+			publishers <- publisher
 		}
+		close(publishers)
+		workersWaitGroup.Wait()
 		close(sink)
 	}()
 	return sink
 }
+
+// goThroughPublisher retrieves the claims of a publisher, records its outcome and pushes its valid claims into the sink,
+// followed by the validators of the feed if the publisher is a feed which changed
+func goThroughPublisher(ctx context.Context, sourceName string, source claim.SourceV2, publisher string, progress *progressRecorder, sink chan<- collectedClaim) {
+	outcome := progress.start(sourceName, publisher)
+	result, err := source.FetchClaims(ctx, publisher)
+	for _, failure := range result.Failures {
+		log.Printf("Publisher %v: rejected %v", publisher, failure)
+	}
+
+	validClaims := make([]claim.Claim, 0, len(result.Claims))
+	for _, c := range result.Claims {
+		if !c.ReferencesVisuals() {
+			validClaims = append(validClaims, c)
+		}
+	}
+	progress.update(outcome, func(outcome *PublisherOutcome) {
+		outcome.Fetched = result.Fetched
+		outcome.Parsed = len(result.Claims)
+		outcome.Rejected = len(result.Failures)
+		outcome.VisualFiltered = len(result.Claims) - len(validClaims)
+		outcome.NotModified = result.NotModified
+		outcome.Err = err
+	})
+	for _, c := range validClaims {
+		sink <- collectedClaim{claim: c, outcome: outcome}
+	}
+	if result.Validators != nil {
+		sink <- collectedClaim{outcome: outcome, validators: result.Validators}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,335 @@
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"fake-or-fact/claim"
+	"fake-or-fact/repo"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClaimConfig_Publishers(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    []claim.Publisher
+		wantErr bool
+	}{
+		{
+			name: "Reads feeds configured as structured entries or plain URLs",
+			config: `{
+				"RealRssFeeds": [{"URL": "http://real.com/feed", "Name": "Real", "Homepage": "http://real.com", "TrustLabel": "mainstream"}],
+				"FakeRssFeeds": ["http://fake.com/feed", {"URL": "http://satire.com/feed", "Name": "Satire", "LanguageCode": "en"}]
+			}`,
+			want: []claim.Publisher{
+				{Name: "Real", Homepage: "http://real.com", TrustLabel: "mainstream"},
+				{Name: "Satire", LanguageCode: "en"},
+			},
+		},
+		{
+			name: "Includes the publishers of scraped sites",
+			config: `{
+				"ScrapeSites": [{"URL": "http://checks.com/list", "Items": "li", "Title": "a", "Link": "a@href", "Date": "time", "Rating": ".rating", "Publisher": {"Name": "Checks"}}]
+			}`,
+			want: []claim.Publisher{{Name: "Checks"}},
+		},
+		{
+			name:    "Rejects invalid scrape configurations",
+			config:  `{"ScrapeSites": [{"URL": "http://checks.com/list", "Items": "li", "Publisher": {"Name": "Checks"}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "Rejects publisher names which are too long",
+			config:  `{"RealRssFeeds": [{"URL": "http://real.com/feed", "Name": "` + strings.Repeat("a", claim.MaxPublisherNameLength+1) + `"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "Rejects unknown fields of feeds",
+			config:  `{"RealRssFeeds": [{"URL": "http://real.com/feed", "Nmae": "Real"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "Rejects unknown fields of Google publishers",
+			config:  `{"GoogleFactCheckPublishers": [{"Site": "checker.com", "LanguageCod": "en"}]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config ClaimConfig
+			if err := json.Unmarshal([]byte(tt.config), &config); err != nil {
+				if !tt.wantErr {
+					t.Fatalf("json.Unmarshal() error = %v", err)
+				}
+				return
+			}
+			got, err := config.Publishers()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ClaimConfig.Publishers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClaimConfig.Publishers() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{
+			name:   "A configuration with only a database is valid",
+			config: `{}`,
+		},
+		{
+			name:   "Accepts SQLite databases",
+			config: `{"Database": {"Dialect": "sqlite3", "ConnectionString": "fake-or-fact.db"}}`,
+		},
+		{
+			name:    "Rejects unsupported database dialects",
+			config:  `{"Database": {"Dialect": "mysql"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Rejects missing connection strings",
+			config:  `{"Database": {"ConnectionString": ""}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Rejects negative save batch sizes",
+			config:  `{"Database": {"SaveBatchSize": -1}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Rejects negative unhealthy run thresholds",
+			config:  `{"UnhealthyRunThreshold": -1}`,
+			wantErr: true,
+		},
+		{
+			name: "A complete configuration is valid",
+			config: `{
+				"RealRssFeeds": [{"URL": "http://real.com/feed", "Name": "Real"}],
+				"RatingRules": [{"Pattern": "(?i)bogus", "Verdict": "false"}],
+				"URLRules": {"StripParams": ["ref_*"], "KeepParams": ["share"], "KeepScheme": true},
+				"CollectionInterval": "6h",
+				"Schedules": {"google": {"Schedule": "0 */6 * * *", "Jitter": "10m"}}
+			}`,
+		},
+		{
+			name:    "Rejects invalid collection intervals",
+			config:  `{"CollectionInterval": "-1h"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Rejects invalid publishers",
+			config:  `{"RealRssFeeds": [{"URL": "http://real.com/feed", "Name": "` + strings.Repeat("a", claim.MaxPublisherNameLength+1) + `"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "Rejects invalid rating rules",
+			config:  `{"RatingRules": [{"Pattern": "(", "Verdict": "false"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "Rejects invalid URL rules",
+			config:  `{"URLRules": {"StripParams": ["utm_*_id"]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Rejects invalid schedules",
+			config:  `{"Schedules": {"rss": {"Schedule": "@daily"}}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config ClaimConfig
+			config.Database.Dialect = "postgres"
+			config.Database.ConnectionString = "host=localhost"
+			if err := json.Unmarshal([]byte(tt.config), &config); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("ClaimConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// batchClaimRepo stores claims in memory, recording the size of every batch and failing batches containing failingURL
+type batchClaimRepo struct {
+	repo.ClaimRepo
+	failingURL string
+	batchSizes []int
+}
+
+func (mock *batchClaimRepo) Save(c claim.Claim, onConflict repo.OnConflict) (repo.SaveResult, error) {
+	if c.URL == mock.failingURL {
+		return 0, errors.New("value too long")
+	}
+	return mock.ClaimRepo.Save(c, onConflict)
+}
+
+func (mock *batchClaimRepo) SaveBatch(claims []claim.Claim, onConflict repo.OnConflict) ([]repo.SaveResult, error) {
+	mock.batchSizes = append(mock.batchSizes, len(claims))
+	for _, c := range claims {
+		if c.URL == mock.failingURL {
+			return nil, errors.New("value too long")
+		}
+	}
+	return mock.ClaimRepo.SaveBatch(claims, onConflict)
+}
+
+// outcomeFeedCache records the number of claims of a feed which were saved when its validators were cached
+type outcomeFeedCache struct {
+	outcome *PublisherOutcome
+	saved   map[string]int
+}
+
+func (mock *outcomeFeedCache) GetValidators(feedURL string) (claim.FeedValidators, error) {
+	return claim.FeedValidators{}, nil
+}
+
+func (mock *outcomeFeedCache) SaveValidators(feedURL string, validators claim.FeedValidators) error {
+	mock.saved[feedURL] = mock.outcome.New + mock.outcome.Duplicate
+	return nil
+}
+
+func TestClaimCollector_persist(t *testing.T) {
+	const feedURL = "https://daily.com/feed"
+	tests := []struct {
+		name       string
+		batchSize  int
+		urls       []string
+		failingURL string
+		feed       bool
+		// whether UpdateRevisedClaims is set, and the title of the stored claim after persisting
+		updateRevised   bool
+		wantStoredTitle string
+		wantSizes       []int
+		wantNew         int
+		wantDup         int
+		wantCached      map[string]int
+	}{
+		{
+			name:      "saves claims in batches",
+			batchSize: 2,
+			urls:      []string{"/1", "/2", "/3", "/4", "/5"},
+			wantSizes: []int{2, 2, 1},
+			wantNew:   5,
+		},
+		{
+			name:      "saves claims one by one without a batch size",
+			urls:      []string{"/1", "/2"},
+			wantSizes: []int{1, 1},
+			wantNew:   2,
+		},
+		{
+			name:            "counts stored and repeated claims as duplicates",
+			batchSize:       10,
+			urls:            []string{"/stored", "/1", "/1"},
+			wantSizes:       []int{3},
+			wantNew:         1,
+			wantDup:         2,
+			wantStoredTitle: "Stored",
+		},
+		{
+			name:            "revises stored claims if UpdateRevisedClaims is set",
+			batchSize:       10,
+			urls:            []string{"/stored"},
+			updateRevised:   true,
+			wantSizes:       []int{1},
+			wantDup:         1,
+			wantStoredTitle: "Claim 0",
+		},
+		{
+			name:      "counts claims with the same canonical URL as duplicates",
+			batchSize: 10,
+			urls:      []string{"/1?utm_source=rss", "/1/", "/stored/#comments"},
+			wantSizes: []int{3},
+			wantNew:   1,
+			wantDup:   2,
+		},
+		{
+			name:       "saves the claims of a failed batch one by one",
+			batchSize:  3,
+			urls:       []string{"/1", "/failing", "/2"},
+			failingURL: "https://daily.com/failing",
+			wantSizes:  []int{3},
+			wantNew:    2,
+		},
+		{
+			name:       "caches the validators of a feed once its claims were saved",
+			batchSize:  2,
+			urls:       []string{"/1", "/2", "/3"},
+			feed:       true,
+			wantSizes:  []int{2, 1},
+			wantNew:    3,
+			wantCached: map[string]int{feedURL: 3},
+		},
+		{
+			name:       "does not cache the validators of a feed whose claims could not all be saved",
+			batchSize:  3,
+			urls:       []string{"/1", "/failing"},
+			failingURL: "https://daily.com/failing",
+			feed:       true,
+			wantSizes:  []int{2},
+			wantNew:    1,
+			wantCached: map[string]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &batchClaimRepo{ClaimRepo: repo.NewMemoryClaimRepo(), failingURL: tt.failingURL}
+			stored, _ := claim.NewClaim("Stored", "Daily", "https://daily.com/stored", claim.KindNews, claim.VerdictTrue, time.Now())
+			claims.ClaimRepo.Save(stored, repo.KeepStored)
+			config := &ClaimConfig{}
+			config.Database.SaveBatchSize = tt.batchSize
+			config.UpdateRevisedClaims = tt.updateRevised
+			outcome := &PublisherOutcome{Publisher: feedURL}
+			feeds := &outcomeFeedCache{outcome: outcome, saved: make(map[string]int)}
+			collector := NewClaimCollector(claims, nil, feeds, config)
+
+			collected := make(chan collectedClaim, len(tt.urls)+1)
+			for i, url := range tt.urls {
+				c, _ := claim.NewClaim(fmt.Sprintf("Claim %v", i), "Daily", "http://daily.com"+url, claim.KindNews, claim.VerdictTrue, time.Now())
+				collected <- collectedClaim{claim: c, outcome: outcome}
+			}
+			if tt.feed {
+				collected <- collectedClaim{outcome: outcome, validators: &claim.FeedValidators{ETag: `"v1"`}}
+			}
+			close(collected)
+			canonicalizer, _ := claim.NewURLCanonicalizer(config.URLRules)
+			collector.persist(collected, canonicalizer, newProgressRecorder(nil))
+
+			if !reflect.DeepEqual(claims.batchSizes, tt.wantSizes) {
+				t.Errorf("batch sizes = %v, want %v", claims.batchSizes, tt.wantSizes)
+			}
+			if outcome.New != tt.wantNew || outcome.Duplicate != tt.wantDup {
+				t.Errorf("outcome new = %v, duplicate = %v, want %v and %v", outcome.New, outcome.Duplicate, tt.wantNew, tt.wantDup)
+			}
+			if tt.wantStoredTitle != "" {
+				var gotTitle string
+				claims.Export(repo.ExportFilter{}, func(c claim.Claim) error {
+					if c.URL == stored.URL {
+						gotTitle = c.Title
+					}
+					return nil
+				})
+				if gotTitle != tt.wantStoredTitle {
+					t.Errorf("stored claim title = %v, want %v", gotTitle, tt.wantStoredTitle)
+				}
+			}
+			if tt.feed && !reflect.DeepEqual(feeds.saved, tt.wantCached) {
+				t.Errorf("saved claims when caching validators = %v, want %v", feeds.saved, tt.wantCached)
+			}
+		})
+	}
+}
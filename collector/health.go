@@ -0,0 +1,56 @@
+package collector
+
+import "fake-or-fact/repo"
+
+// PublisherHealth describes a publisher which has been failing during the latest collection runs
+type PublisherHealth struct {
+	Source    string
+	Publisher string
+	// the number of consecutive latest runs in which the publisher errored or returned nothing although it changed
+	ConsecutiveFailures int
+	// the error of the latest run, empty if the publisher returned nothing without erroring
+	LastError string
+}
+
+// UnhealthyPublishers returns the publishers which errored or returned nothing during at least threshold consecutive runs,
+// counting from the latest run. Runs must be ordered from latest to oldest. Runs in which a publisher was not queried are ignored for it.
+func UnhealthyPublishers(runs []repo.CollectionRun, threshold int) []PublisherHealth {
+	type publisherKey struct{ source, publisher string }
+	healthByPublisher := make(map[publisherKey]*PublisherHealth)
+	// publishers which returned claims since their latest failures
+	recovered := make(map[publisherKey]bool)
+	order := make([]publisherKey, 0)
+
+	for _, run := range runs {
+		for _, publisherRun := range run.Publishers {
+			key := publisherKey{publisherRun.Source, publisherRun.Publisher}
+			if recovered[key] {
+				continue
+			}
+			if !isFailing(publisherRun) {
+				recovered[key] = true
+				continue
+			}
+			health, found := healthByPublisher[key]
+			if !found {
+				health = &PublisherHealth{Source: key.source, Publisher: key.publisher, LastError: publisherRun.Error}
+				healthByPublisher[key] = health
+				order = append(order, key)
+			}
+			health.ConsecutiveFailures++
+		}
+	}
+
+	unhealthy := make([]PublisherHealth, 0)
+	for _, key := range order {
+		if health := healthByPublisher[key]; health.ConsecutiveFailures >= threshold {
+			unhealthy = append(unhealthy, *health)
+		}
+	}
+	return unhealthy
+}
+
+// isFailing returns true if the publisher errored or returned no valid item, unless it reported that nothing changed
+func isFailing(publisherRun repo.PublisherRun) bool {
+	return publisherRun.Error != "" || (publisherRun.Parsed == 0 && !publisherRun.NotModified)
+}
@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"fake-or-fact/repo"
+	"reflect"
+	"testing"
+)
+
+func Test_UnhealthyPublishers(t *testing.T) {
+	healthy := func(publisher string) repo.PublisherRun {
+		return repo.PublisherRun{Source: "google", Publisher: publisher, Fetched: 10, Parsed: 10}
+	}
+	empty := func(publisher string) repo.PublisherRun {
+		return repo.PublisherRun{Source: "google", Publisher: publisher}
+	}
+	erroring := func(publisher string, err string) repo.PublisherRun {
+		return repo.PublisherRun{Source: "google", Publisher: publisher, Error: err}
+	}
+	rejected := func(publisher string) repo.PublisherRun {
+		return repo.PublisherRun{Source: "scrape", Publisher: publisher, Fetched: 10, Rejected: 10}
+	}
+	notModified := func(publisher string) repo.PublisherRun {
+		return repo.PublisherRun{Source: "fake-rss", Publisher: publisher, NotModified: true}
+	}
+	// from latest to oldest
+	runs := []repo.CollectionRun{
+		{Publishers: []repo.PublisherRun{erroring("a.com", "timeout"), empty("b.com"), empty("c.com"), healthy("d.com"), notModified("e.com"), rejected("f.com")}},
+		{Publishers: []repo.PublisherRun{erroring("a.com", "bad gateway"), empty("b.com"), healthy("c.com"), empty("d.com"), notModified("e.com"), rejected("f.com")}},
+		// a run in which b.com was not queried
+		{Publishers: []repo.PublisherRun{empty("a.com"), empty("c.com"), empty("d.com"), rejected("f.com")}},
+		{Publishers: []repo.PublisherRun{healthy("a.com"), empty("b.com"), empty("c.com"), empty("d.com")}},
+	}
+	tests := []struct {
+		name      string
+		threshold int
+		want      []PublisherHealth
+	}{
+		{
+			name:      "Counts consecutive failures from the latest run",
+			threshold: 3,
+			want: []PublisherHealth{
+				{Source: "google", Publisher: "a.com", ConsecutiveFailures: 3, LastError: "timeout"},
+				{Source: "google", Publisher: "b.com", ConsecutiveFailures: 3},
+				{Source: "scrape", Publisher: "f.com", ConsecutiveFailures: 3},
+			},
+		},
+		{
+			name:      "Excludes publishers below the threshold",
+			threshold: 4,
+			want:      []PublisherHealth{},
+		},
+		{
+			name:      "Includes publishers failing in the latest run only",
+			threshold: 1,
+			want: []PublisherHealth{
+				{Source: "google", Publisher: "a.com", ConsecutiveFailures: 3, LastError: "timeout"},
+				{Source: "google", Publisher: "b.com", ConsecutiveFailures: 3},
+				{Source: "google", Publisher: "c.com", ConsecutiveFailures: 1},
+				{Source: "scrape", Publisher: "f.com", ConsecutiveFailures: 3},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnhealthyPublishers(runs, tt.threshold); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnhealthyPublishers() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
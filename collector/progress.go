@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"fake-or-fact/repo"
+	"sync"
+	"time"
+)
+
+// Progress is a snapshot of the progress of a collection run
+type Progress struct {
+	Running bool
+	// the groups of sources collected by the run
+	Groups []string
+	// zero if no run was started yet
+	StartedAt time.Time
+	// zero while the run is in progress
+	FinishedAt time.Time
+	// the number of publishers to query during the run
+	PublisherCount int
+	// the outcomes of the publishers queried so far, including the ones being queried
+	Publishers []repo.PublisherRun
+
+	outcomes []PublisherOutcome
+}
+
+// progressRecorder records the outcomes of publishers queried concurrently during a run.
+// Outcomes must only be modified through the recorder so that snapshots can be taken at any time.
+type progressRecorder struct {
+	mutex          sync.Mutex
+	groups         []string
+	running        bool
+	startedAt      time.Time
+	finishedAt     time.Time
+	publisherCount int
+	outcomes       []*PublisherOutcome
+}
+
+func newProgressRecorder(groups []string) *progressRecorder {
+	return &progressRecorder{groups: groups}
+}
+
+// begin marks the start of a run which will query the given number of publishers
+func (recorder *progressRecorder) begin(publisherCount int) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.running = true
+	recorder.startedAt = time.Now()
+	recorder.publisherCount = publisherCount
+}
+
+// finish marks the end of the run
+func (recorder *progressRecorder) finish() {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.running = false
+	recorder.finishedAt = time.Now()
+}
+
+// start records the outcome of a publisher which is about to be queried, and returns it so that it can be updated
+func (recorder *progressRecorder) start(source string, publisher string) *PublisherOutcome {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	outcome := &PublisherOutcome{Source: source, Publisher: publisher}
+	recorder.outcomes = append(recorder.outcomes, outcome)
+	return outcome
+}
+
+// update modifies an outcome returned by start
+func (recorder *progressRecorder) update(outcome *PublisherOutcome, modify func(*PublisherOutcome)) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	modify(outcome)
+}
+
+// snapshot returns a copy of the current progress
+func (recorder *progressRecorder) snapshot() Progress {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	progress := Progress{
+		Running:        recorder.running,
+		Groups:         recorder.groups,
+		StartedAt:      recorder.startedAt,
+		FinishedAt:     recorder.finishedAt,
+		PublisherCount: recorder.publisherCount,
+		Publishers:     make([]repo.PublisherRun, 0, len(recorder.outcomes)),
+		outcomes:       make([]PublisherOutcome, 0, len(recorder.outcomes)),
+	}
+	for _, outcome := range recorder.outcomes {
+		progress.outcomes = append(progress.outcomes, *outcome)
+		progress.Publishers = append(progress.Publishers, outcome.asPublisherRun())
+	}
+	return progress
+}
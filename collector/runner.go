@@ -0,0 +1,142 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrRunInProgress is returned when a collection run is requested while one of its groups of sources is being collected
+var ErrRunInProgress = errors.New("A collection run is already in progress")
+
+// Runner runs a ClaimCollector on demand or on schedule, ensuring that a group of sources is never collected by overlapping runs.
+// Runs collecting different groups can run concurrently.
+type Runner struct {
+	collector ClaimCollector
+	// the context of runs started on demand
+	ctx   context.Context
+	mutex sync.Mutex
+	// the groups of sources being collected
+	running map[string]bool
+	// the recorder of the latest run of each group of sources
+	progress map[string]*progressRecorder
+	// the runs started in the background which are not finished
+	background sync.WaitGroup
+}
+
+// NewRunner creates a Runner for the given collector. Runs started on demand are cancelled once ctx is done
+func NewRunner(ctx context.Context, collector ClaimCollector) *Runner {
+	return &Runner{collector: collector, ctx: ctx, running: make(map[string]bool), progress: make(map[string]*progressRecorder)}
+}
+
+// Run collects the given groups of sources (or every group if none is given) and waits for the run to finish.
+// Returns ErrRunInProgress if one of the groups is already being collected
+func (runner *Runner) Run(ctx context.Context, groups ...string) ([]PublisherOutcome, error) {
+	progress, err := runner.acquire(groups)
+	if err != nil {
+		return nil, err
+	}
+	defer runner.release(progress.groups)
+	return runner.collector.collectAndPersist(ctx, progress, progress.groups), nil
+}
+
+// Start collects the given groups of sources (or every group if none is given) in the background.
+// Returns ErrRunInProgress if one of the groups is already being collected
+func (runner *Runner) Start(groups ...string) error {
+	progress, err := runner.acquire(groups)
+	if err != nil {
+		return err
+	}
+	runner.background.Add(1)
+	go func() {
+		defer runner.background.Done()
+		defer runner.release(progress.groups)
+		runner.collector.collectAndPersist(runner.ctx, progress, progress.groups)
+	}()
+	return nil
+}
+
+// Wait waits for the runs started in the background to finish. Runs must not be started while waiting
+func (runner *Runner) Wait() {
+	runner.background.Wait()
+}
+
+// Progress returns the progress of the latest run of every group of sources, ordered by group.
+// Runs collecting several groups are only returned once.
+func (runner *Runner) Progress() []Progress {
+	runner.mutex.Lock()
+	recorders := make([]*progressRecorder, 0, len(runner.progress))
+	for _, group := range SourceGroups() {
+		if recorder, ok := runner.progress[group]; ok && !containsRecorder(recorders, recorder) {
+			recorders = append(recorders, recorder)
+		}
+	}
+	runner.mutex.Unlock()
+
+	progress := make([]Progress, 0, len(recorders))
+	for _, recorder := range recorders {
+		progress = append(progress, recorder.snapshot())
+	}
+	return progress
+}
+
+// acquire marks the groups of sources (or every group if none is given) as being collected and returns the recorder tracking the progress of the run
+func (runner *Runner) acquire(groups []string) (*progressRecorder, error) {
+	if len(groups) == 0 {
+		groups = SourceGroups()
+	}
+	for _, group := range groups {
+		if !contains(SourceGroups(), group) {
+			return nil, &unknownGroupError{group}
+		}
+	}
+
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+	for _, group := range groups {
+		if runner.running[group] {
+			return nil, ErrRunInProgress
+		}
+	}
+	recorder := newProgressRecorder(groups)
+	// the run is reported as running until it begins collecting
+	recorder.begin(0)
+	for _, group := range groups {
+		runner.running[group] = true
+		runner.progress[group] = recorder
+	}
+	return recorder, nil
+}
+
+func (runner *Runner) release(groups []string) {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+	for _, group := range groups {
+		delete(runner.running, group)
+	}
+}
+
+func containsRecorder(recorders []*progressRecorder, recorder *progressRecorder) bool {
+	for _, r := range recorders {
+		if r == recorder {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownGroupError is returned when a run is requested for a group of sources which does not exist
+type unknownGroupError struct {
+	group string
+}
+
+func (e *unknownGroupError) Error() string {
+	return fmt.Sprintf("Unknown group of sources '%v'", e.group)
+}
+
+// IsUnknownGroupError returns true if the error was caused by requesting a group of sources which does not exist
+func IsUnknownGroupError(err error) bool {
+	var unknownGroupErr *unknownGroupError
+	return errors.As(err, &unknownGroupErr)
+}
@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"context"
+	"fake-or-fact/repo"
+	"testing"
+)
+
+func TestRunner_PreventsOverlappingRuns(t *testing.T) {
+	runner := NewRunner(context.Background(), NewClaimCollector(nil, nil, nil, &ClaimConfig{}))
+	progress, err := runner.acquire([]string{googleSourceName})
+	if err != nil {
+		t.Fatalf("Runner.acquire() error = %v, want nil", err)
+	}
+	if actualProgress := runner.Progress(); len(actualProgress) != 1 || !actualProgress[0].Running {
+		t.Errorf("Runner.Progress() = %#v, want a single running progress", actualProgress)
+	}
+	if err := runner.Start(); err != ErrRunInProgress {
+		t.Errorf("Runner.Start() error = %v, want %v", err, ErrRunInProgress)
+	}
+	if _, err := runner.Run(context.Background(), googleSourceName); err != ErrRunInProgress {
+		t.Errorf("Runner.Run() error = %v, want %v", err, ErrRunInProgress)
+	}
+	if _, err := runner.acquire([]string{fakeRssSourceName, realRssSourceName}); err != nil {
+		t.Errorf("Runner.acquire() of other groups error = %v, want nil", err)
+	}
+	if actualProgress := runner.Progress(); len(actualProgress) != 2 {
+		t.Errorf("Runner.Progress() = %#v, want the progress of both runs", actualProgress)
+	}
+
+	runner.release(progress.groups)
+	if _, err := runner.acquire([]string{googleSourceName}); err != nil {
+		t.Errorf("Runner.acquire() after release error = %v, want nil", err)
+	}
+}
+
+func TestRunner_RejectsUnknownGroups(t *testing.T) {
+	runner := NewRunner(context.Background(), NewClaimCollector(nil, nil, nil, &ClaimConfig{}))
+	if err := runner.Start("unknown"); !IsUnknownGroupError(err) {
+		t.Errorf("Runner.Start() error = %v, want an unknown group error", err)
+	}
+}
+
+// blockingRunRepo blocks saving runs until release is closed, signalling saving when a run is being saved
+type blockingRunRepo struct {
+	saving  chan struct{}
+	release chan struct{}
+	saved   []repo.CollectionRun
+}
+
+func (mock *blockingRunRepo) Save(run repo.CollectionRun) error {
+	mock.saving <- struct{}{}
+	<-mock.release
+	mock.saved = append(mock.saved, run)
+	return nil
+}
+
+func (mock *blockingRunRepo) GetLatest(limit int) ([]repo.CollectionRun, error) {
+	return mock.saved, nil
+}
+
+func TestRunner_WaitsForStartedRuns(t *testing.T) {
+	runs := &blockingRunRepo{saving: make(chan struct{}), release: make(chan struct{})}
+	runner := NewRunner(context.Background(), NewClaimCollector(nil, runs, nil, &ClaimConfig{}))
+	if err := runner.Start(googleSourceName); err != nil {
+		t.Fatalf("Runner.Start() error = %v, want nil", err)
+	}
+	<-runs.saving
+
+	waited := make(chan struct{})
+	go func() {
+		runner.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		t.Fatalf("Runner.Wait() returned before the started run finished")
+	default:
+	}
+	close(runs.release)
+	<-waited
+	if len(runs.saved) != 1 {
+		t.Errorf("Saved runs = %v, want the started run", runs.saved)
+	}
+}
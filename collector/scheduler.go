@@ -0,0 +1,144 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// SourceSchedule configures when and how the claims of a group of sources are collected
+type SourceSchedule struct {
+	// a cron expression (e.g. "0 */6 * * *") or a descriptor (e.g. "@every 6h" or "@daily").
+	// Defaults to running once every CollectionInterval, starting when the collector starts, if empty
+	Schedule string
+	// the maximum random delay added to every scheduled run, e.g. "10m"
+	Jitter string
+	// the maximum number of publishers of the group queried at the same time. Defaults to 1
+	Concurrency int
+	// whether the group is also collected when the collector starts
+	RunOnStart bool
+}
+
+func (schedule SourceSchedule) concurrency() int {
+	if schedule.Concurrency <= 0 {
+		return 1
+	}
+	return schedule.Concurrency
+}
+
+// Clock tells the time and waits for durations to elapse, it is replaced in tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Scheduler collects each group of sources according to its own SourceSchedule
+type Scheduler struct {
+	// collects a single group, returns ErrRunInProgress if the group is already being collected
+	run     func(ctx context.Context, group string) error
+	clock   Clock
+	random  func() float64
+	entries []scheduleEntry
+}
+
+type scheduleEntry struct {
+	group      string
+	schedule   cron.Schedule
+	jitter     time.Duration
+	runOnStart bool
+}
+
+// NewScheduler creates a Scheduler collecting every group of sources through the runner, according to the configured schedules.
+// Returns an error if a schedule is invalid.
+func NewScheduler(runner *Runner, config *ClaimConfig) (*Scheduler, error) {
+	run := func(ctx context.Context, group string) error {
+		_, err := runner.Run(ctx, group)
+		return err
+	}
+	return newScheduler(run, config, realClock{}, rand.Float64)
+}
+
+func newScheduler(run func(ctx context.Context, group string) error, config *ClaimConfig, clock Clock, random func() float64) (*Scheduler, error) {
+	interval, intervalErr := config.Interval()
+	if intervalErr != nil {
+		return nil, intervalErr
+	}
+	for group := range config.Schedules {
+		if !contains(SourceGroups(), group) {
+			return nil, fmt.Errorf("Unknown group of sources in schedules: '%v'", group)
+		}
+	}
+
+	entries := make([]scheduleEntry, 0, len(SourceGroups()))
+	for _, group := range SourceGroups() {
+		sourceSchedule := config.Schedules[group]
+		expression, runOnStart := sourceSchedule.Schedule, sourceSchedule.RunOnStart
+		if expression == "" {
+			expression, runOnStart = fmt.Sprintf("@every %v", interval), true
+		}
+		schedule, scheduleErr := cron.ParseStandard(expression)
+		if scheduleErr != nil {
+			return nil, fmt.Errorf("Invalid schedule for %v: %v", group, scheduleErr)
+		}
+		var jitter time.Duration
+		if sourceSchedule.Jitter != "" {
+			var jitterErr error
+			if jitter, jitterErr = time.ParseDuration(sourceSchedule.Jitter); jitterErr != nil || jitter < 0 {
+				return nil, fmt.Errorf("Invalid jitter for %v: '%v'", group, sourceSchedule.Jitter)
+			}
+		}
+		entries = append(entries, scheduleEntry{group: group, schedule: schedule, jitter: jitter, runOnStart: runOnStart})
+	}
+	return &Scheduler{run: run, clock: clock, random: random, entries: entries}, nil
+}
+
+// Run collects every group of sources whenever its schedule is due, until the context is done.
+// A scheduled run is skipped if the group is still being collected when it is due.
+func (scheduler *Scheduler) Run(ctx context.Context) {
+	var entriesWaitGroup sync.WaitGroup
+	for _, entry := range scheduler.entries {
+		entriesWaitGroup.Add(1)
+		go func(entry scheduleEntry) {
+			defer entriesWaitGroup.Done()
+			scheduler.runEntry(ctx, entry)
+		}(entry)
+	}
+	entriesWaitGroup.Wait()
+}
+
+func (scheduler *Scheduler) runEntry(ctx context.Context, entry scheduleEntry) {
+	if entry.runOnStart {
+		scheduler.runGroup(ctx, entry.group)
+	}
+	for {
+		now := scheduler.clock.Now()
+		delay := entry.schedule.Next(now).Sub(now) + time.Duration(scheduler.random()*float64(entry.jitter))
+		select {
+		case <-ctx.Done():
+			return
+		case <-scheduler.clock.After(delay):
+		}
+		scheduler.runGroup(ctx, entry.group)
+	}
+}
+
+func (scheduler *Scheduler) runGroup(ctx context.Context, group string) {
+	if err := scheduler.run(ctx, group); err == ErrRunInProgress {
+		log.Printf("Skipping scheduled collection of %v since it is already in progress", group)
+	}
+}
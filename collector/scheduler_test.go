@@ -0,0 +1,266 @@
+package collector
+
+import (
+	"context"
+	"fake-or-fact/claim"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time only moves forward when Advance is called
+type fakeClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []fakeClockWaiter
+}
+
+type fakeClockWaiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	return clock.now
+}
+
+func (clock *fakeClock) After(d time.Duration) <-chan time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	c := make(chan time.Time, 1)
+	clock.waiters = append(clock.waiters, fakeClockWaiter{clock.now.Add(d), c})
+	return c
+}
+
+// Advance moves the clock forward and fires the waiters which are due
+func (clock *fakeClock) Advance(d time.Duration) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	clock.now = clock.now.Add(d)
+	pending := make([]fakeClockWaiter, 0, len(clock.waiters))
+	for _, waiter := range clock.waiters {
+		if waiter.at.After(clock.now) {
+			pending = append(pending, waiter)
+		} else {
+			waiter.c <- clock.now
+		}
+	}
+	clock.waiters = pending
+}
+
+// waitForWaiters blocks until the given number of goroutines are waiting on the clock
+func (clock *fakeClock) waitForWaiters(t *testing.T, count int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		clock.mutex.Lock()
+		waiting := len(clock.waiters)
+		clock.mutex.Unlock()
+		if waiting >= count {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %v goroutines to wait on the clock", count)
+}
+
+// recordedRuns records the groups collected by a scheduler
+type recordedRuns struct {
+	mutex sync.Mutex
+	runs  []string
+}
+
+func (recorded *recordedRuns) run(ctx context.Context, group string) error {
+	recorded.mutex.Lock()
+	defer recorded.mutex.Unlock()
+	recorded.runs = append(recorded.runs, group)
+	return nil
+}
+
+func (recorded *recordedRuns) count(group string) int {
+	recorded.mutex.Lock()
+	defer recorded.mutex.Unlock()
+	count := 0
+	for _, run := range recorded.runs {
+		if run == group {
+			count++
+		}
+	}
+	return count
+}
+
+func TestScheduler_Run(t *testing.T) {
+	start := time.Date(2020, 9, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		schedule SourceSchedule
+		random   float64
+		// the runs of the google group expected after advancing the clock by each step
+		steps        []time.Duration
+		expectedRuns []int
+	}{
+		{
+			name:         "Runs on every interval",
+			schedule:     SourceSchedule{Schedule: "@every 1h"},
+			steps:        []time.Duration{59 * time.Minute, time.Minute, time.Hour},
+			expectedRuns: []int{0, 1, 2},
+		},
+		{
+			name:         "Runs when the cron expression is due",
+			schedule:     SourceSchedule{Schedule: "0 * * * *"},
+			steps:        []time.Duration{29 * time.Minute, time.Minute, time.Hour},
+			expectedRuns: []int{0, 1, 2},
+		},
+		{
+			name:         "Delays runs by the jitter",
+			schedule:     SourceSchedule{Schedule: "@every 1h", Jitter: "10m"},
+			random:       0.5,
+			steps:        []time.Duration{time.Hour, 5 * time.Minute},
+			expectedRuns: []int{0, 1},
+		},
+		{
+			name:         "Runs on start if requested",
+			schedule:     SourceSchedule{Schedule: "@every 1h", RunOnStart: true},
+			steps:        []time.Duration{0, time.Hour},
+			expectedRuns: []int{1, 2},
+		},
+		{
+			name:         "Runs on start and every collection interval without a schedule",
+			schedule:     SourceSchedule{},
+			steps:        []time.Duration{0, 14 * time.Hour, time.Hour},
+			expectedRuns: []int{1, 1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock(start)
+			recorded := new(recordedRuns)
+			config := &ClaimConfig{Schedules: map[string]SourceSchedule{
+				googleSourceName:  tt.schedule,
+				fakeRssSourceName: {Schedule: "@weekly"},
+				realRssSourceName: {Schedule: "@weekly"},
+			}}
+			scheduler, err := newScheduler(recorded.run, config, clock, func() float64 { return tt.random })
+			if err != nil {
+				t.Fatalf("newScheduler() error = %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				scheduler.Run(ctx)
+				close(done)
+			}()
+			for i, step := range tt.steps {
+				clock.waitForWaiters(t, len(SourceGroups()))
+				clock.Advance(step)
+				if step > 0 {
+					clock.waitForWaiters(t, len(SourceGroups()))
+				}
+				if actualRuns := recorded.count(googleSourceName); actualRuns != tt.expectedRuns[i] {
+					t.Errorf("Runs after step %v = %v, want %v", i, actualRuns, tt.expectedRuns[i])
+				}
+			}
+			cancel()
+			<-done
+			if actualRuns := recorded.count(fakeRssSourceName); actualRuns != 0 {
+				t.Errorf("Runs of %v = %v, want 0", fakeRssSourceName, actualRuns)
+			}
+		})
+	}
+}
+
+func TestNewScheduler_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		schedules map[string]SourceSchedule
+	}{
+		{"Invalid cron expression", map[string]SourceSchedule{googleSourceName: {Schedule: "every hour"}}},
+		{"Invalid jitter", map[string]SourceSchedule{googleSourceName: {Schedule: "@hourly", Jitter: "soon"}}},
+		{"Unknown group", map[string]SourceSchedule{"unknown": {Schedule: "@hourly"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &ClaimConfig{Schedules: tt.schedules}
+			if _, err := newScheduler(new(recordedRuns).run, config, newFakeClock(time.Now()), func() float64 { return 0 }); err == nil {
+				t.Errorf("newScheduler() error = nil, want an error")
+			}
+		})
+	}
+}
+
+// concurrencySource records the maximum number of publishers queried at the same time.
+// Queries signal started and are blocked until release is closed.
+type concurrencySource struct {
+	started chan string
+	release chan struct{}
+	mutex   sync.Mutex
+	current int
+	max     int
+}
+
+func newConcurrencySource() *concurrencySource {
+	return &concurrencySource{started: make(chan string), release: make(chan struct{})}
+}
+
+func (source *concurrencySource) FetchClaims(ctx context.Context, publisher string) (claim.FetchResult, error) {
+	source.mutex.Lock()
+	source.current++
+	if source.current > source.max {
+		source.max = source.current
+	}
+	source.mutex.Unlock()
+
+	select {
+	case source.started <- publisher:
+	case <-source.release:
+	}
+	<-source.release
+
+	source.mutex.Lock()
+	source.current--
+	source.mutex.Unlock()
+	return claim.FetchResult{}, nil
+}
+
+func Test_goThroughClaims_LimitsConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+	}{
+		{"Queries publishers one at a time", 1},
+		{"Queries several publishers at a time", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newConcurrencySource()
+			group := sourceGroup{name: "test", source: source, publishers: []string{"a", "b", "c", "d", "e", "f"}}
+			progress := newProgressRecorder([]string{group.name})
+			sink := goThroughClaims(context.Background(), group, tt.concurrency, progress)
+			for i := 0; i < tt.concurrency; i++ {
+				<-source.started
+			}
+			// every worker is blocked in a query, so no other publisher can be queried until they are released
+			source.mutex.Lock()
+			blocked := source.current
+			source.mutex.Unlock()
+			if blocked != tt.concurrency {
+				t.Errorf("Publishers being queried = %v, want %v", blocked, tt.concurrency)
+			}
+			close(source.release)
+			for range sink {
+			}
+			if source.max != tt.concurrency {
+				t.Errorf("Publishers queried at the same time = %v, want %v", source.max, tt.concurrency)
+			}
+			if actualCount := len(progress.snapshot().Publishers); actualCount != len(group.publishers) {
+				t.Errorf("Publishers queried = %v, want %v", actualCount, len(group.publishers))
+			}
+		})
+	}
+}
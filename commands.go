@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fake-or-fact/claim"
+	"fake-or-fact/collector"
+	"fake-or-fact/repo"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// command is a subcommand of the fake-or-fact binary
+type command struct {
+	name string
+	// a one-line description shown in the usage
+	description string
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{"serve", "serve the game and its API over HTTP", runServe},
+	{"collect", "collect claims once, or on the configured schedules with -schedule", runCollect},
+	{"migrate", "migrate the database schema", runMigrate},
+	{"cluster", "group every stored claim with its near duplicates", runCluster},
+	{"canonicalize", "canonicalize the URLs of stored claims and merge duplicates", runCanonicalize},
+	{"import", "import claims from JSONL or CSV files", runImport},
+	{"export", "export stored claims as JSONL or CSV", runExport},
+	{"check-config", "validate the configuration", runCheckConfig},
+}
+
+// runCommand runs the subcommand named by the first argument with the remaining arguments.
+// Without arguments the binary serves HTTP and collects claims on schedule, as it did before it had subcommands.
+func runCommand(args []string) error {
+	if len(args) == 0 {
+		return runServe([]string{"-collect"})
+	}
+	name := args[0]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(args[1:])
+		}
+	}
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		fmt.Fprint(os.Stderr, usage())
+		return flag.ErrHelp
+	}
+	return fmt.Errorf("Unknown command '%v'\n%v", name, usage())
+}
+
+// usage lists every command, each command describes its own flags when run with -h
+func usage() string {
+	var builder strings.Builder
+	builder.WriteString("Usage: fake-or-fact <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&builder, "  %-14v%v\n", cmd.name, cmd.description)
+	}
+	builder.WriteString("\nRun 'fake-or-fact <command> -h' for the flags of a command.\n")
+	return builder.String()
+}
+
+// runCollect collects claims from the given groups of sources once and exits, or keeps collecting them on their schedules with -schedule
+func runCollect(args []string) error {
+	flags := flag.NewFlagSet("collect", flag.ContinueOnError)
+	groups := flags.String("groups", "", "comma-separated groups of sources to collect, e.g. google,scrape. Every group is collected if empty")
+	schedule := flags.Bool("schedule", false, "keep collecting every group on its configured schedule until interrupted")
+	configPath := configFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	db, err := openDatabase(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := repo.Migrate(db); err != nil {
+		return fmt.Errorf("Failed to migrate database: %v", err)
+	}
+	if err := savePublishers(repo.NewPublisherRepo(db), config); err != nil {
+		return err
+	}
+	runner := newRunner(ctx, repo.NewClaimRepo(db), repo.NewRunRepo(db), repo.NewFeedCache(db), &config)
+
+	if *schedule {
+		scheduler, err := collector.NewScheduler(runner, &config)
+		if err != nil {
+			return fmt.Errorf("Invalid collection schedules: %v", err)
+		}
+		scheduler.Run(ctx)
+		return nil
+	}
+	outcomes, err := runner.Run(ctx, splitList(*groups)...)
+	if err != nil {
+		return err
+	}
+	for _, outcome := range outcomes {
+		log.Println(outcome)
+	}
+	return nil
+}
+
+// runMigrate applies the migrations which were not applied yet, or migrates the schema up or down to the version given by -to
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	to := flags.Int("to", repo.LatestVersion(), "schema version to migrate to, reverting later migrations. 0 reverts every migration")
+	status := flags.Bool("status", false, "list the migrations and whether they were applied, without migrating")
+	configPath := configFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	db, err := openConfiguredDatabase(*configPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if !*status {
+		if err := repo.MigrateTo(db, *to); err != nil {
+			return err
+		}
+		log.Printf("Migrated database to version %v", *to)
+	}
+	statuses, err := repo.GetMigrationStatus(db)
+	if err != nil {
+		return err
+	}
+	for _, migration := range statuses {
+		applied := "pending"
+		if migration.AppliedAt != nil {
+			applied = "applied at " + migration.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%3d %-40v %v\n", migration.Version, migration.Name, applied)
+	}
+	return nil
+}
+
+// runCluster regroups every stored claim with its near duplicates, e.g. to group the claims stored before clusters existed
+func runCluster(args []string) error {
+	flags := flag.NewFlagSet("cluster", flag.ContinueOnError)
+	configPath := configFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	db, err := openConfiguredDatabase(*configPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := repo.Migrate(db); err != nil {
+		return fmt.Errorf("Failed to migrate database: %v", err)
+	}
+	grouped, err := repo.RebuildClusters(db)
+	if err != nil {
+		return err
+	}
+	log.Printf("Grouped %v claims with an older near duplicate", grouped)
+	return nil
+}
+
+// runCanonicalize canonicalizes the URLs of stored claims with the configured URL rules, e.g. after the rules changed,
+// and merges the claims sharing a canonical URL
+func runCanonicalize(args []string) error {
+	flags := flag.NewFlagSet("canonicalize", flag.ContinueOnError)
+	configPath := configFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	canonicalizer, err := claim.NewURLCanonicalizer(config.URLRules)
+	if err != nil {
+		return err
+	}
+	db, err := openDatabase(context.Background(), config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := repo.Migrate(db); err != nil {
+		return fmt.Errorf("Failed to migrate database: %v", err)
+	}
+	backfill, err := repo.CanonicalizeURLs(db, canonicalizer)
+	if err != nil {
+		return err
+	}
+	log.Printf("Canonicalized the URLs of %v claims, merged %v duplicates", backfill.Canonicalized, backfill.Merged)
+	return nil
+}
+
+// runCheckConfig validates the configuration without connecting to the database
+func runCheckConfig(args []string) error {
+	flags := flag.NewFlagSet("check-config", flag.ContinueOnError)
+	configPath := configFlag(flags)
+	printConfig := flags.Bool("print", false, "print the effective configuration, with secrets redacted")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if *printConfig {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(config); err != nil {
+			return err
+		}
+	}
+	log.Printf("%v is valid", *configPath)
+	return nil
+}
+
+// interruptContext returns a context which is cancelled on SIGINT or SIGTERM, or when the returned function is called
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+	return ctx, cancel
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func Test_runCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "an unknown command returns the usage",
+			args:    []string{"serv"},
+			wantErr: "Unknown command 'serv'",
+		},
+		{
+			name:    "help returns flag.ErrHelp",
+			args:    []string{"help"},
+			wantErr: flag.ErrHelp.Error(),
+		},
+		{
+			name:    "unknown flags of a command return an error",
+			args:    []string{"export", "-verbose"},
+			wantErr: "flag provided but not defined",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runCommand(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("runCommand(%v) error = %v, want %v", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_usage(t *testing.T) {
+	got := usage()
+	for _, cmd := range commands {
+		if !strings.Contains(got, cmd.name) || !strings.Contains(got, cmd.description) {
+			t.Errorf("usage() = %v, want it to describe %v", got, cmd.name)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+)
+
+// Validator is implemented by configurations which can tell whether their fields are valid
+type Validator interface {
+	Validate() error
+}
+
+// Load reads the JSON file at path into the struct pointed to by config, then:
+//   - overrides fields with the environment variable named by their `env` tag, if it is set
+//   - fills fields which are still zero with the value of their `default` tag
+//   - validates the configuration if it implements Validator
+//
+// Unknown fields in the file are rejected so that typos do not go unnoticed.
+// Types decoding themselves through UnmarshalJSON must decode their objects with UnmarshalStrict for their fields to be checked.
+func Load(path string, config interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Failed to read config file: %v", err)
+	}
+	if err := load(data, config, os.LookupEnv); err != nil {
+		return fmt.Errorf("Invalid config file %v: %v", path, err)
+	}
+	return nil
+}
+
+// UnmarshalStrict is json.Unmarshal rejecting unknown fields, meant for the UnmarshalJSON methods of configuration types
+// since the decoder of Load does not reject the unknown fields of the values decoding themselves
+func UnmarshalStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+func load(data []byte, config interface{}, lookupEnv func(string) (string, bool)) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return describeDecodeError(data, err)
+	}
+	value := reflect.ValueOf(config).Elem()
+	if err := walkFields(value, func(field reflect.Value, tag reflect.StructTag) error {
+		name, hasEnv := tag.Lookup("env")
+		if !hasEnv {
+			return nil
+		}
+		envValue, isSet := lookupEnv(name)
+		if !isSet {
+			return nil
+		}
+		if err := setField(field, envValue); err != nil {
+			return fmt.Errorf("Invalid value for environment variable %v: %v", name, err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := walkFields(value, func(field reflect.Value, tag reflect.StructTag) error {
+		defaultValue, hasDefault := tag.Lookup("default")
+		if !hasDefault || !field.IsZero() {
+			return nil
+		}
+		return setField(field, defaultValue)
+	}); err != nil {
+		return err
+	}
+	if validator, ok := config.(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+// walkFields calls visit for every field of a struct, descending into nested and embedded structs
+func walkFields(value reflect.Value, visit func(field reflect.Value, tag reflect.StructTag) error) error {
+	for i := 0; i < value.NumField(); i++ {
+		field, structField := value.Field(i), value.Type().Field(i)
+		if structField.PkgPath != "" && !structField.Anonymous {
+			continue
+		}
+		if field.Kind() == reflect.Struct {
+			if err := walkFields(field, visit); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := visit(field, structField.Tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setField parses a string into a string, integer or boolean field
+func setField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("'%v' is not an integer", value)
+		}
+		field.SetInt(parsed)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("'%v' is not a boolean", value)
+		}
+		field.SetBool(parsed)
+	default:
+		return fmt.Errorf("Unsupported field type %v", field.Type())
+	}
+	return nil
+}
+
+// describeDecodeError adds the line and column at which decoding failed to syntax and type errors
+func describeDecodeError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, column := position(data, syntaxErr.Offset)
+		return fmt.Errorf("line %v, column %v: %v", line, column, err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		line, column := position(data, typeErr.Offset)
+		return fmt.Errorf("line %v, column %v: %v should be of type %v, not %v", line, column, typeErr.Field, typeErr.Type, typeErr.Value)
+	}
+	return err
+}
+
+// position returns the line and column of a byte offset, starting at 1
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	line, column := 1, 1
+	for _, b := range data[:offset] {
+		if b == '\n' {
+			line, column = line+1, 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
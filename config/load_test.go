@@ -0,0 +1,147 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testConfig struct {
+	Database struct {
+		Dialect  string `env:"TEST_DIALECT" default:"postgres"`
+		Password Secret `env:"TEST_PASSWORD"`
+	}
+	Workers      int  `env:"TEST_WORKERS" default:"4"`
+	Verbose      bool `env:"TEST_VERBOSE"`
+	Name         string
+	Tags         []string
+	RealRssFeeds []testFeed
+}
+
+// testFeed is configured as a plain URL or as an object, decoding itself like the feeds of the collector's configuration
+type testFeed struct {
+	URL  string
+	Name string
+}
+
+func (feed *testFeed) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &feed.URL); err == nil {
+		return nil
+	}
+	type testFeedFields testFeed
+	return UnmarshalStrict(data, (*testFeedFields)(feed))
+}
+
+type validatedConfig struct {
+	Name string
+}
+
+func (config validatedConfig) Validate() error {
+	if config.Name == "" {
+		return errors.New("Missing Name")
+	}
+	return nil
+}
+
+func Test_load(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		env     map[string]string
+		want    testConfig
+		wantErr string
+	}{
+		{
+			name: "zero fields with a default tag are filled",
+			data: `{"Name": "game", "Tags": ["a"]}`,
+			want: newTestConfig("postgres", "", 4, false, "game", []string{"a"}),
+		},
+		{
+			name: "values of the file are kept over defaults",
+			data: `{"Database": {"Dialect": "sqlite3", "Password": "p"}, "Workers": 2}`,
+			want: newTestConfig("sqlite3", "p", 2, false, "", nil),
+		},
+		{
+			name: "environment variables override the file",
+			data: `{"Database": {"Dialect": "sqlite3", "Password": "p"}, "Workers": 2}`,
+			env:  map[string]string{"TEST_PASSWORD": "secret", "TEST_WORKERS": "8", "TEST_VERBOSE": "true", "TEST_DIALECT": ""},
+			want: newTestConfig("postgres", "secret", 8, true, "", nil),
+		},
+		{
+			name:    "invalid environment variables return an error naming them",
+			data:    `{}`,
+			env:     map[string]string{"TEST_WORKERS": "many"},
+			wantErr: "TEST_WORKERS",
+		},
+		{
+			name:    "unknown fields return an error",
+			data:    `{"Nmae": "game"}`,
+			wantErr: `unknown field "Nmae"`,
+		},
+		{
+			name:    "unknown fields of values decoding themselves return an error",
+			data:    `{"RealRssFeeds": ["http://a.com/feed", {"URL": "http://b.com/feed", "Nmae": "B"}]}`,
+			wantErr: `unknown field "Nmae"`,
+		},
+		{
+			name:    "syntax errors return an error with their position",
+			data:    "{\n  \"Name\": \"game\",\n}",
+			wantErr: "line 3, column 2",
+		},
+		{
+			name:    "type errors return an error with their position and field",
+			data:    "{\n  \"Workers\": \"4\"\n}",
+			wantErr: "line 2, column 17: Workers should be of type int, not string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookupEnv := func(name string) (string, bool) {
+				value, isSet := tt.env[name]
+				return value, isSet
+			}
+			var got testConfig
+			err := load([]byte(tt.data), &got, lookupEnv)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_load_Validator(t *testing.T) {
+	noEnv := func(string) (string, bool) { return "", false }
+	var config validatedConfig
+	if err := load([]byte(`{}`), &config, noEnv); err == nil || err.Error() != "Missing Name" {
+		t.Errorf("load() error = %v, want the validation error", err)
+	}
+	if err := load([]byte(`{"Name": "game"}`), &config, noEnv); err != nil {
+		t.Errorf("load() error = %v", err)
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	var config testConfig
+	err := Load("testdata/missing.json", &config)
+	if err == nil || !strings.Contains(err.Error(), "missing.json") {
+		t.Errorf("Load() error = %v, want an error naming the file", err)
+	}
+}
+
+func newTestConfig(dialect string, password Secret, workers int, verbose bool, name string, tags []string) testConfig {
+	config := testConfig{Workers: workers, Verbose: verbose, Name: name, Tags: tags}
+	config.Database.Dialect = dialect
+	config.Database.Password = password
+	return config
+}
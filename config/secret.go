@@ -0,0 +1,28 @@
+package config
+
+// Secret is a configuration value which must never be logged, such as a password or an API key.
+// It is redacted when formatted or encoded as JSON, Value returns the actual secret.
+type Secret string
+
+const redacted = "[REDACTED]"
+
+// Value returns the actual secret
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String returns a placeholder for non-empty secrets, so that they are redacted by fmt verbs
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return `"` + s.String() + `"`
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + s.String() + `"`), nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	config := struct {
+		User     string
+		Password Secret
+	}{"admin", "hunter2"}
+
+	formatted := []string{
+		fmt.Sprint(config.Password),
+		fmt.Sprintf("%v", config),
+		fmt.Sprintf("%+v", config),
+		fmt.Sprintf("%#v", config),
+	}
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	formatted = append(formatted, string(encoded))
+	for _, output := range formatted {
+		if strings.Contains(output, "hunter2") || !strings.Contains(output, redacted) {
+			t.Errorf("Secret was not redacted: %v", output)
+		}
+	}
+	if config.Password.Value() != "hunter2" {
+		t.Errorf("Secret.Value() = %v, want hunter2", config.Password.Value())
+	}
+	if Secret("").String() != "" {
+		t.Errorf("Secret.String() = %v, want empty secrets to stay empty", Secret("").String())
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fake-or-fact/collector"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+const (
+	initialConnectDelay = time.Second
+	maxConnectDelay     = 30 * time.Second
+)
+
+// openConfiguredDatabase loads the configuration file at path and connects to its database
+func openConfiguredDatabase(configPath string) (*gorm.DB, error) {
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return openDatabase(context.Background(), config)
+}
+
+// openDatabase connects to the configured database, retrying with an increasing delay until Database.ConnectAttempts attempts failed
+// or the context is done
+func openDatabase(ctx context.Context, config collector.ClaimConfig) (*gorm.DB, error) {
+	dialect := config.Database.Dialect
+	open := func() (*gorm.DB, error) {
+		db, err := gorm.Open(dialect, config.Database.ConnectionString.Value())
+		if err == nil && dialect == "sqlite3" {
+			// SQLite fails concurrent writes with "database is locked" instead of waiting, and every connection to :memory: opens a different database
+			db.DB().SetMaxOpenConns(1)
+		}
+		return db, err
+	}
+	db, err := connectWithRetry(ctx, open, config.Database.ConnectAttempts, initialConnectDelay, time.After)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to the %v database: %v", dialect, err)
+	}
+	return db, nil
+}
+
+// connectWithRetry calls open until it succeeds, at most attempts times (at least once).
+// The delay between attempts starts at initialDelay and doubles after every attempt, up to maxConnectDelay.
+func connectWithRetry(ctx context.Context, open func() (*gorm.DB, error), attempts int, initialDelay time.Duration, after func(time.Duration) <-chan time.Time) (*gorm.DB, error) {
+	delay := initialDelay
+	for attempt := 1; ; attempt++ {
+		db, err := open()
+		if err == nil {
+			return db, nil
+		}
+		if attempt >= attempts {
+			return nil, fmt.Errorf("giving up after %v attempts: %v", attempt, err)
+		}
+		log.Printf("Failed to connect to the database (attempt %v of %v), retrying in %v: %v", attempt, attempts, delay, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-after(delay):
+		}
+		if delay *= 2; delay > maxConnectDelay {
+			delay = maxConnectDelay
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+func Test_connectWithRetry(t *testing.T) {
+	connectErr := errors.New("connection refused")
+	tests := []struct {
+		name string
+		// the number of failed attempts before open succeeds
+		failures   int
+		attempts   int
+		wantErr    bool
+		wantDelays []time.Duration
+	}{
+		{
+			name:       "returns the database of the first successful attempt",
+			failures:   0,
+			attempts:   5,
+			wantDelays: nil,
+		},
+		{
+			name:       "doubles the delay between failed attempts",
+			failures:   3,
+			attempts:   5,
+			wantDelays: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:       "caps the delay between failed attempts",
+			failures:   7,
+			attempts:   8,
+			wantDelays: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second},
+		},
+		{
+			name:       "gives up once every attempt failed",
+			failures:   3,
+			attempts:   3,
+			wantErr:    true,
+			wantDelays: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:     "attempts to connect at least once",
+			failures: 1,
+			attempts: 0,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			open := func() (*gorm.DB, error) {
+				calls++
+				if calls <= tt.failures {
+					return nil, connectErr
+				}
+				return &gorm.DB{}, nil
+			}
+			var delays []time.Duration
+			after := func(d time.Duration) <-chan time.Time {
+				delays = append(delays, d)
+				elapsed := make(chan time.Time, 1)
+				elapsed <- time.Time{}
+				return elapsed
+			}
+
+			db, err := connectWithRetry(context.Background(), open, tt.attempts, time.Second, after)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("connectWithRetry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && db == nil {
+				t.Errorf("connectWithRetry() returned no database")
+			}
+			if !reflect.DeepEqual(delays, tt.wantDelays) {
+				t.Errorf("connectWithRetry() waited %v, want %v", delays, tt.wantDelays)
+			}
+		})
+	}
+}
+
+func Test_connectWithRetry_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	open := func() (*gorm.DB, error) {
+		return nil, errors.New("connection refused")
+	}
+	never := func(time.Duration) <-chan time.Time {
+		return nil
+	}
+	if _, err := connectWithRetry(ctx, open, 5, time.Second, never); err != context.Canceled {
+		t.Errorf("connectWithRetry() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fake-or-fact/repo"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const HEALTHZ_PATH = "/healthz"
+const READYZ_PATH = "/readyz"
+
+const readinessTimeout = 2 * time.Second
+
+// Pinger checks that a connection to the database can be established, it is implemented by *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// ReadinessResponse describes whether the server can serve requests, along with the status of the latest collection run
+type ReadinessResponse struct {
+	Ready bool
+	// the error encountered while reaching the database, empty if it could be reached
+	DatabaseError string
+	// the latest collection run, nil if there is none or if it could not be retrieved
+	LastCollection *CollectionStatus
+}
+
+// CollectionStatus summarizes a collection run
+type CollectionStatus struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// the number of publishers queried during the run
+	Publishers int
+	// the number of publishers which errored during the run
+	FailedPublishers int
+}
+
+// HealthRoute responds with 200 as long as the server is running
+func HealthRoute(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"Status": "ok"})
+}
+
+// ReadyRoute responds with 200 if the database can be reached and 503 otherwise, along with the status of the latest collection run
+func ReadyRoute(db Pinger, runs repo.RunRepo) func(*gin.Context) {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+		if err := db.PingContext(ctx); err != nil {
+			c.JSON(http.StatusServiceUnavailable, ReadinessResponse{Ready: false, DatabaseError: err.Error()})
+			return
+		}
+
+		response := ReadinessResponse{Ready: true}
+		latestRuns, err := runs.GetLatest(1)
+		if err == nil && len(latestRuns) > 0 {
+			response.LastCollection = asCollectionStatus(latestRuns[0])
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func asCollectionStatus(run repo.CollectionRun) *CollectionStatus {
+	failed := 0
+	for _, publisher := range run.Publishers {
+		if publisher.Error != "" {
+			failed++
+		}
+	}
+	return &CollectionStatus{
+		StartedAt:        run.StartedAt,
+		FinishedAt:       run.FinishedAt,
+		Publishers:       len(run.Publishers),
+		FailedPublishers: failed,
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fake-or-fact/repo"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type mockPinger struct {
+	err error
+}
+
+func (mock mockPinger) PingContext(ctx context.Context) error {
+	return mock.err
+}
+
+func Test_ReadyRoute(t *testing.T) {
+	startedAt := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	finishedAt := startedAt.Add(time.Minute)
+	latestRun := repo.CollectionRun{
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		Publishers: []repo.PublisherRun{
+			{Source: "google", Publisher: "a.com", Fetched: 3},
+			{Source: "google", Publisher: "b.com", Error: "timeout"},
+		},
+	}
+	tests := []struct {
+		name       string
+		pingErr    error
+		runs       []repo.CollectionRun
+		wantStatus int
+		want       ReadinessResponse
+	}{
+		{
+			name:       "ready with the status of the latest run when the database can be reached",
+			runs:       []repo.CollectionRun{latestRun, {StartedAt: startedAt.Add(-time.Hour)}},
+			wantStatus: http.StatusOK,
+			want: ReadinessResponse{
+				Ready:          true,
+				LastCollection: &CollectionStatus{StartedAt: startedAt, FinishedAt: finishedAt, Publishers: 2, FailedPublishers: 1},
+			},
+		},
+		{
+			name:       "ready without a collection status when nothing was collected yet",
+			wantStatus: http.StatusOK,
+			want:       ReadinessResponse{Ready: true},
+		},
+		{
+			name:       "unavailable when the database cannot be reached",
+			pingErr:    errors.New("connection refused"),
+			runs:       []repo.CollectionRun{latestRun},
+			wantStatus: http.StatusServiceUnavailable,
+			want:       ReadinessResponse{Ready: false, DatabaseError: "connection refused"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.Default()
+			router.GET(READYZ_PATH, ReadyRoute(mockPinger{tt.pingErr}, &mockRunRepo{runs: tt.runs}))
+
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, READYZ_PATH, nil))
+
+			if response.Code != tt.wantStatus {
+				t.Errorf("ReadyRoute() status = %v, want %v", response.Code, tt.wantStatus)
+			}
+			var got ReadinessResponse
+			if err := json.Unmarshal(response.Body.Bytes(), &got); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadyRoute() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_HealthRoute(t *testing.T) {
+	router := gin.Default()
+	router.GET(HEALTHZ_PATH, HealthRoute)
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, HEALTHZ_PATH, nil))
+
+	if response.Code != http.StatusOK {
+		t.Errorf("HealthRoute() status = %v, want %v", response.Code, http.StatusOK)
+	}
+}
+
+func Test_serveUntilDone(t *testing.T) {
+	tests := []struct {
+		name string
+		addr string
+		// how long the collection run in progress keeps running after the context is done, it never stops if negative
+		collectionDuration time.Duration
+		wantErr            bool
+	}{
+		{
+			name:               "waits for collection runs to stop once the context is done",
+			addr:               "127.0.0.1:0",
+			collectionDuration: 50 * time.Millisecond,
+		},
+		{
+			name:               "gives up on collection runs which do not stop before the timeout",
+			addr:               "127.0.0.1:0",
+			collectionDuration: -1,
+			wantErr:            true,
+		},
+		{
+			name:    "returns the error of the server if it cannot listen",
+			addr:    "127.0.0.1:-1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var collecting sync.WaitGroup
+			collecting.Add(1)
+			collectionDuration := tt.collectionDuration
+			go func() {
+				<-ctx.Done()
+				if collectionDuration >= 0 {
+					time.Sleep(collectionDuration)
+					collecting.Done()
+				}
+			}()
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			server := &http.Server{Addr: tt.addr, Handler: http.NotFoundHandler()}
+			err := serveUntilDone(ctx, server, 500*time.Millisecond, collecting.Wait)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("serveUntilDone() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
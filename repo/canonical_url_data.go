@@ -0,0 +1,118 @@
+package repo
+
+import (
+	"fake-or-fact/claim"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// URLBackfill is the outcome of canonicalizing the URLs of the stored claims
+type URLBackfill struct {
+	// the number of claims whose URL was replaced by its canonical form
+	Canonicalized int
+	// the number of claims which were merged into a claim with the same canonical URL
+	Merged int
+}
+
+// CanonicalizeURLs replaces the URL of every stored claim by its canonical form, keeping the previous URL as its original URL,
+// and merges the claims sharing a canonical URL in a single transaction.
+// Claims sharing a canonical URL are merged as if they were collected from oldest to latest review date:
+// the oldest claim is kept and is revised by the later ones, which are deleted once their reviews were moved to the kept claim
+func CanonicalizeURLs(db *gorm.DB, canonicalizer claim.URLCanonicalizer) (URLBackfill, error) {
+	backfill := URLBackfill{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		duplicates := make(map[string][]ClaimData)
+		ordered := tx.Preload("Reviews").Order("reviewed_at ASC, id ASC").Limit(exportBatchSize)
+		for offset := 0; ; offset += exportBatchSize {
+			batch := make([]ClaimData, 0, exportBatchSize)
+			if err := ordered.Offset(offset).Find(&batch).Error; err != nil {
+				return err
+			}
+			for _, claimData := range batch {
+				canonicalURL := canonicalizer.Canonicalize(claimData.URL)
+				duplicates[canonicalURL] = append(duplicates[canonicalURL], claimData)
+			}
+			if len(batch) < exportBatchSize {
+				break
+			}
+		}
+
+		canonicalURLs := make([]string, 0, len(duplicates))
+		for canonicalURL := range duplicates {
+			canonicalURLs = append(canonicalURLs, canonicalURL)
+		}
+		sort.Strings(canonicalURLs)
+		for _, canonicalURL := range canonicalURLs {
+			claims := duplicates[canonicalURL]
+			if len(claims) == 1 && claims[0].URL == canonicalURL {
+				continue
+			}
+			if err := mergeClaims(tx, canonicalURL, claims); err != nil {
+				return fmt.Errorf("Failed to canonicalize the claims of '%v': %v", canonicalURL, err)
+			}
+			for _, claimData := range claims {
+				if claimData.URL != canonicalURL {
+					backfill.Canonicalized++
+				}
+			}
+			backfill.Merged += len(claims) - 1
+		}
+		return nil
+	})
+	if err != nil {
+		return URLBackfill{}, err
+	}
+	return backfill, nil
+}
+
+// mergeClaims deletes every claim but the first one, which is revised by the others in order and stored with the canonical URL.
+// The revisions and reviews of the deleted claims are moved to the kept claim, a review replacing the kept review with the same URL,
+// and the claims of their clusters join its cluster
+func mergeClaims(tx *gorm.DB, canonicalURL string, claims []ClaimData) error {
+	kept, merged := claims[0], claims[1:]
+	for _, claimData := range merged {
+		if err := tx.Model(&ClaimRevisionData{}).Where("claim_id = ?", claimData.ID).UpdateColumn("claim_id", kept.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&ClaimData{}).Where("cluster_id = ?", claimData.ID).UpdateColumn("cluster_id", kept.ClusterID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("claim_id = ?", claimData.ID).Delete(&ReviewData{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("claim_id = ?", claimData.ID).Delete(&TitleBandData{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", claimData.ID).Delete(&ClaimData{}).Error; err != nil {
+			return err
+		}
+	}
+
+	err := tx.Model(&ClaimData{}).Where("id = ?", kept.ID).UpdateColumns(map[string]interface{}{
+		"url":          canonicalURL,
+		"original_url": claim.OriginalURLOf(kept.OriginalURL, kept.URL, canonicalURL),
+	}).Error
+	if err != nil {
+		return err
+	}
+	// revisions are recorded one microsecond apart, the precision of postgres timestamps, so that they are listed in the order they were merged in
+	revisedAt := time.Now()
+	for i, claimData := range merged {
+		result, err := revise(tx, &kept, claimData, revisedAt.Add(time.Duration(i)*time.Microsecond))
+		if err != nil {
+			return err
+		}
+		// revise only moves the reviews of claims which revise the kept claim
+		if result == ClaimExisted {
+			for _, review := range claimData.Reviews {
+				if err := reviseReview(tx, kept.ID, review); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"fake-or-fact/claim"
+	"reflect"
+	"testing"
+)
+
+func TestCanonicalizeURLs(t *testing.T) {
+	db := openTestDB(t)
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	tracked := conformanceClaim("http://checker.com/claim/?utm_source=rss", claim.KindFactCheck, claim.VerdictFalse, 0)
+	tracked.Reviews = []claim.Review{{PublisherName: "Checker", PublisherSite: "checker.com", URL: tracked.URL, Title: "Tracked", TextualRating: "False", Verdict: claim.VerdictFalse, LanguageCode: "en", ReviewedAt: tracked.ReviewedAt}}
+	rerated := conformanceClaim("https://checker.com/claim", claim.KindFactCheck, claim.VerdictTrue, 2)
+	amp := conformanceClaim("https://checker.com/claim/amp", claim.KindFactCheck, claim.VerdictTrue, 1)
+	amp.Reviews = []claim.Review{{PublisherName: "Other", PublisherSite: "other.com", URL: "https://other.com/claim", Title: "Other", TextualRating: "True", Verdict: claim.VerdictTrue, LanguageCode: "en", ReviewedAt: amp.ReviewedAt}}
+	// the same claim without any change, whose review must still be kept
+	unchanged := rerated
+	unchanged.URL = "https://checker.com/claim?fbclid=1"
+	unchanged.Reviews = []claim.Review{{PublisherName: "Third", PublisherSite: "third.com", URL: "https://third.com/claim", Title: "Third", TextualRating: "True", Verdict: claim.VerdictTrue, LanguageCode: "en", ReviewedAt: rerated.ReviewedAt}}
+	insecure := conformanceClaim("http://daily.com/news/", claim.KindNews, claim.VerdictTrue, 3)
+	canonical := conformanceClaim("https://daily.com/canonical", claim.KindNews, claim.VerdictTrue, 4)
+	claims := NewClaimRepo(db)
+	saveAll(t, claims, tracked, rerated, amp, insecure, canonical, unchanged)
+	canonicalizer, _ := claim.NewURLCanonicalizer(claim.URLRules{})
+
+	backfill, err := CanonicalizeURLs(db, canonicalizer)
+	if err != nil {
+		t.Fatalf("CanonicalizeURLs() error = %v", err)
+	}
+	if want := (URLBackfill{Canonicalized: 4, Merged: 3}); backfill != want {
+		t.Errorf("CanonicalizeURLs() = %+v, want %+v", backfill, want)
+	}
+	stored := make(map[string]claim.Claim)
+	claims.Export(ExportFilter{}, func(c claim.Claim) error {
+		stored[c.URL] = c
+		return nil
+	})
+	if len(stored) != 3 {
+		t.Fatalf("stored claims = %v, want the merged, insecure and canonical claims", stored)
+	}
+	merged := stored["https://checker.com/claim"]
+	if merged.Title != rerated.Title || merged.Verdict != claim.VerdictTrue || merged.OriginalURL != tracked.URL {
+		t.Errorf("merged claim = %+v, want the values of the latest claim and the original URL of the oldest", merged)
+	}
+	reviewTitles := make([]string, 0)
+	for _, review := range merged.Reviews {
+		reviewTitles = append(reviewTitles, review.Title)
+	}
+	if want := []string{"Tracked", "Other", "Third"}; !reflect.DeepEqual(reviewTitles, want) {
+		t.Errorf("merged claim reviews = %v, want the reviews of every merged claim %v", reviewTitles, want)
+	}
+	history, err := claims.GetHistory(merged.ID)
+	if err != nil {
+		t.Fatalf("GetHistory() error = %v", err)
+	}
+	revisedTitles := make([]string, 0)
+	for _, revision := range history.Revisions {
+		revisedTitles = append(revisedTitles, revision.Title)
+	}
+	if want := []string{amp.Title, tracked.Title}; !reflect.DeepEqual(revisedTitles, want) {
+		t.Errorf("merged claim revisions = %v, want %v", revisedTitles, want)
+	}
+	if got := stored["https://daily.com/news"].OriginalURL; got != insecure.URL {
+		t.Errorf("canonicalized claim original URL = %v, want %v", got, insecure.URL)
+	}
+	if got := stored[canonical.URL].OriginalURL; got != "" {
+		t.Errorf("canonical claim original URL = %v, want it unchanged", got)
+	}
+
+	again, err := CanonicalizeURLs(db, canonicalizer)
+	if err != nil || again != (URLBackfill{}) {
+		t.Errorf("CanonicalizeURLs() of canonical URLs = %+v, %v, want nothing canonicalized", again, err)
+	}
+}
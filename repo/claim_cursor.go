@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"encoding/base64"
+	"fake-or-fact/claim"
+	"fmt"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// DefaultPageLimit is the number of claims returned by ClaimRepo.Get if the query has no limit
+	DefaultPageLimit = 20
+	// MaxPageLimit is the maximum number of claims returned by ClaimRepo.Get, whatever the limit of the query
+	MaxPageLimit = 100
+)
+
+// PageLimit returns the maximum number of claims returned by ClaimRepo.Get for the limit of a query:
+// DefaultPageLimit if the limit is not positive, and at most MaxPageLimit
+func PageLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return limit
+}
+
+// ClaimCursor is the position of a stored claim in the order claims are returned by ClaimRepo.Get:
+// from latest to oldest review date, then from highest to lowest ID for claims reviewed at the same time
+type ClaimCursor struct {
+	ReviewedAt time.Time
+	ID         string
+}
+
+// CursorOf returns the position of a stored claim
+func CursorOf(c claim.Claim) ClaimCursor {
+	return ClaimCursor{ReviewedAt: c.ReviewedAt, ID: c.ID}
+}
+
+// Precedes returns true if the claim at the cursor is returned before the claim at the other cursor
+func (cursor ClaimCursor) Precedes(other ClaimCursor) bool {
+	if cursor.ReviewedAt.Equal(other.ReviewedAt) {
+		return cursor.ID > other.ID
+	}
+	return cursor.ReviewedAt.After(other.ReviewedAt)
+}
+
+// Encode returns the cursor as an opaque string which can be used in URLs
+func (cursor ClaimCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursor.ReviewedAt.UTC().Format(time.RFC3339Nano) + "," + cursor.ID))
+}
+
+// DecodeClaimCursor returns the cursor encoded by ClaimCursor.Encode, or an error if the string is not an encoded cursor
+func DecodeClaimCursor(encoded string) (ClaimCursor, error) {
+	invalidErr := fmt.Errorf("Invalid cursor '%v'", encoded)
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ClaimCursor{}, invalidErr
+	}
+	parts := strings.SplitN(string(decoded), ",", 2)
+	if len(parts) != 2 {
+		return ClaimCursor{}, invalidErr
+	}
+	reviewedAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return ClaimCursor{}, invalidErr
+	}
+	id, err := uuid.FromString(parts[1])
+	if err != nil {
+		return ClaimCursor{}, invalidErr
+	}
+	return ClaimCursor{ReviewedAt: reviewedAt, ID: id.String()}, nil
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestDecodeClaimCursor(t *testing.T) {
+	cursor := ClaimCursor{ReviewedAt: time.Date(2020, 5, 1, 10, 0, 0, 123456000, time.FixedZone("Paris", 2*60*60)), ID: "6ba7b810-9dad-11d1-80b4-00c04fd430c8"}
+	tests := []struct {
+		name    string
+		encoded string
+		want    ClaimCursor
+		wantErr bool
+	}{
+		{name: "decodes encoded cursors", encoded: cursor.Encode(), want: cursor},
+		{name: "rejects strings which are not base64", encoded: "not a cursor!", wantErr: true},
+		{name: "rejects cursors without an ID", encoded: base64.RawURLEncoding.EncodeToString([]byte("2020-05-01T10:00:00Z")), wantErr: true},
+		{name: "rejects cursors with an invalid time", encoded: base64.RawURLEncoding.EncodeToString([]byte("yesterday," + cursor.ID)), wantErr: true},
+		{name: "rejects cursors with an invalid ID", encoded: base64.RawURLEncoding.EncodeToString([]byte("2020-05-01T10:00:00Z,1")), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeClaimCursor(tt.encoded)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeClaimCursor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.ReviewedAt.Equal(tt.want.ReviewedAt) || got.ID != tt.want.ID {
+				t.Errorf("DecodeClaimCursor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimCursor_Precedes(t *testing.T) {
+	noon := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		a    ClaimCursor
+		b    ClaimCursor
+		want bool
+	}{
+		{name: "later claims precede older ones", a: ClaimCursor{noon, "a"}, b: ClaimCursor{noon.Add(-time.Second), "b"}, want: true},
+		{name: "older claims follow later ones", a: ClaimCursor{noon.Add(-time.Second), "b"}, b: ClaimCursor{noon, "a"}, want: false},
+		{name: "claims reviewed at the same time are ordered by ID", a: ClaimCursor{noon, "b"}, b: ClaimCursor{noon.In(time.FixedZone("Tokyo", 9*60*60)), "a"}, want: true},
+		{name: "a claim does not precede itself", a: ClaimCursor{noon, "a"}, b: ClaimCursor{noon, "a"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Precedes(tt.b); got != tt.want {
+				t.Errorf("ClaimCursor.Precedes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageLimit(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{limit: 0, want: DefaultPageLimit},
+		{limit: -1, want: DefaultPageLimit},
+		{limit: 5, want: 5},
+		{limit: MaxPageLimit + 1, want: MaxPageLimit},
+	}
+	for _, tt := range tests {
+		if got := PageLimit(tt.limit); got != tt.want {
+			t.Errorf("PageLimit(%v) = %v, want %v", tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,631 @@
+package repo
+
+import (
+	"crypto/md5"
+	"errors"
+	"fake-or-fact/claim"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// claimRepoImplementations returns a new empty ClaimRepo of every implementation, which must all pass the same tests.
+// The postgres implementation is skipped unless FOF_TEST_POSTGRES_URL is set.
+var claimRepoImplementations = map[string]func(t *testing.T) ClaimRepo{
+	"memory": func(t *testing.T) ClaimRepo {
+		return NewMemoryClaimRepo()
+	},
+	"sqlite": func(t *testing.T) ClaimRepo {
+		db := openTestDB(t)
+		if err := Migrate(db); err != nil {
+			t.Fatalf("Migrate() error = %v", err)
+		}
+		return NewClaimRepo(db)
+	},
+	"postgres": func(t *testing.T) ClaimRepo {
+		db := openPostgresTestDB(t)
+		if err := Migrate(db); err != nil {
+			t.Fatalf("Migrate() error = %v", err)
+		}
+		if err := db.Exec("DELETE FROM claim_revision; DELETE FROM claim_review; DELETE FROM claim;").Error; err != nil {
+			t.Fatalf("Failed to empty the claim tables: %v", err)
+		}
+		return NewClaimRepo(db)
+	},
+}
+
+var conformanceTime = time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// conformanceClaim returns a valid claim reviewed the given number of hours after conformanceTime.
+// Its title is the hash of its URL, so that claims with different URLs are not near duplicates
+func conformanceClaim(url string, kind claim.Kind, verdict claim.Verdict, hours int) claim.Claim {
+	title := fmt.Sprintf("%x", md5.Sum([]byte(url)))
+	newClaim, err := claim.NewClaim(title, "Daily", url, kind, verdict, conformanceTime.Add(time.Duration(hours)*time.Hour))
+	if err != nil {
+		panic(err)
+	}
+	return newClaim
+}
+
+// normalized returns the claim without its IDs and with every time in UTC,
+// so that claims can be compared to the saved claims whatever the location their times were read in
+func normalized(c claim.Claim) claim.Claim {
+	c.ID = ""
+	c.ClusterID = ""
+	c.ReviewedAt = c.ReviewedAt.UTC()
+	if !c.ClaimDate.IsZero() {
+		c.ClaimDate = c.ClaimDate.UTC()
+	}
+	if c.Reviews != nil {
+		reviews := make([]claim.Review, 0, len(c.Reviews))
+		for _, review := range c.Reviews {
+			review.ReviewedAt = review.ReviewedAt.UTC()
+			reviews = append(reviews, review)
+		}
+		c.Reviews = reviews
+	}
+	return c
+}
+
+func claimURLs(claims []claim.Claim) []string {
+	urls := make([]string, 0, len(claims))
+	for _, c := range claims {
+		urls = append(urls, c.URL)
+	}
+	return urls
+}
+
+func saveAll(t *testing.T, claims ClaimRepo, toSave ...claim.Claim) {
+	for _, c := range toSave {
+		if _, err := claims.Save(c, KeepStored); err != nil {
+			t.Fatalf("ClaimRepo.Save(%v) error = %v", c.URL, err)
+		}
+	}
+}
+
+func TestClaimRepo_SaveAndGet(t *testing.T) {
+	paris := time.FixedZone("Paris", 2*60*60)
+	reviewed := conformanceClaim("http://daily.com/reviewed", claim.KindFactCheck, claim.VerdictMostlyFalse, 0)
+	reviewed.Claimant = "A politician"
+	reviewed.ClaimDate = conformanceTime.Add(-48 * time.Hour).In(paris)
+	reviewed.AppearanceURL = "http://social.com/post"
+	reviewed.OriginalURL = "http://daily.com/reviewed/?utm_source=rss"
+	reviewed.Reviews = []claim.Review{
+		{PublisherName: "Checker", PublisherSite: "checker.com", URL: "http://checker.com/2", Title: "Second", TextualRating: "Wrong", Verdict: claim.VerdictFalse, LanguageCode: "en", ReviewedAt: conformanceTime.Add(-time.Hour)},
+		{PublisherName: "Daily", PublisherSite: "daily.com", URL: "http://daily.com/reviewed", Title: "First", TextualRating: "Mostly false", Verdict: claim.VerdictMostlyFalse, LanguageCode: "en", ReviewedAt: conformanceTime.Add(-2 * time.Hour)},
+	}
+	wantReviewed := normalized(reviewed)
+	wantReviewed.Reviews = []claim.Review{wantReviewed.Reviews[1], wantReviewed.Reviews[0]}
+
+	for name, newClaimRepo := range claimRepoImplementations {
+		t.Run(name, func(t *testing.T) {
+			claims := newClaimRepo(t)
+			saveAll(t, claims, reviewed)
+
+			got, err := claims.Get(ClaimQuery{IsFact: false, ReviewedBefore: conformanceTime.Add(time.Hour)})
+			if err != nil {
+				t.Fatalf("ClaimRepo.Get() error = %v", err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("ClaimRepo.Get() returned %v claims, want 1", len(got))
+			}
+			if got[0].ID == "" {
+				t.Errorf("ClaimRepo.Get() returned a claim without ID")
+			}
+			if !reflect.DeepEqual(normalized(got[0]), wantReviewed) {
+				t.Errorf("ClaimRepo.Get() = %#v, want %#v", normalized(got[0]), wantReviewed)
+			}
+		})
+	}
+}
+
+func TestClaimRepo_Save(t *testing.T) {
+	stored := conformanceClaim("http://daily.com/a", claim.KindFactCheck, claim.VerdictFalse, 0)
+	stored.Reviews = []claim.Review{{PublisherName: "Daily", URL: "http://daily.com/a", TextualRating: "False", Verdict: claim.VerdictFalse, ReviewedAt: conformanceTime}}
+	revised := stored
+	revised.Title = "Revised title"
+	revised.Verdict = claim.VerdictMostlyTrue
+	revised.IsFact = true
+	revised.Reviews = nil
+	redated := stored
+	redated.ReviewedAt = stored.ReviewedAt.Add(time.Hour)
+	rerated := revised
+	rerated.Reviews = []claim.Review{
+		{PublisherName: "Daily", URL: "http://daily.com/a", TextualRating: "Mostly true", Verdict: claim.VerdictMostlyTrue, ReviewedAt: conformanceTime.Add(time.Hour)},
+		{PublisherName: "Checker", URL: "http://checker.com/a", TextualRating: "Correct", Verdict: claim.VerdictTrue, ReviewedAt: conformanceTime.Add(2 * time.Hour)},
+	}
+
+	tests := []struct {
+		name       string
+		saved      claim.Claim
+		onConflict OnConflict
+		want       SaveResult
+		// the claim stored after saving
+		wantStored claim.Claim
+	}{
+		{
+			name:       "inserts claims with a new URL",
+			saved:      conformanceClaim("http://daily.com/b", claim.KindNews, claim.VerdictTrue, 0),
+			want:       ClaimInserted,
+			wantStored: stored,
+		},
+		{
+			name:       "keeps the stored claim with the same URL",
+			saved:      revised,
+			onConflict: KeepStored,
+			want:       ClaimExisted,
+			wantStored: stored,
+		},
+		{
+			name:       "updates the title and verdict of the stored claim with the same URL",
+			saved:      revised,
+			onConflict: UpdateStored,
+			want:       ClaimUpdated,
+			wantStored: func() claim.Claim { c := revised; c.Reviews = stored.Reviews; return c }(),
+		},
+		{
+			name:       "updates the stored review with the same URL and adds the other reviews of a revised claim",
+			saved:      rerated,
+			onConflict: UpdateStored,
+			want:       ClaimUpdated,
+			wantStored: rerated,
+		},
+		{
+			name:       "updates the review date of the stored claim with the same URL",
+			saved:      redated,
+			onConflict: UpdateStored,
+			want:       ClaimUpdated,
+			wantStored: redated,
+		},
+		{
+			name:       "does not update stored claims which did not change",
+			saved:      stored,
+			onConflict: UpdateStored,
+			want:       ClaimExisted,
+			wantStored: stored,
+		},
+	}
+	for name, newClaimRepo := range claimRepoImplementations {
+		newClaimRepo := newClaimRepo
+		t.Run(name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					claims := newClaimRepo(t)
+					saveAll(t, claims, stored)
+
+					got, err := claims.Save(tt.saved, tt.onConflict)
+					if err != nil {
+						t.Fatalf("ClaimRepo.Save() error = %v", err)
+					}
+					if got != tt.want {
+						t.Errorf("ClaimRepo.Save() = %v, want %v", got, tt.want)
+					}
+					var gotStored []claim.Claim
+					claims.Export(ExportFilter{Publishers: []string{"Daily"}}, func(c claim.Claim) error {
+						if c.URL == stored.URL {
+							gotStored = append(gotStored, normalized(c))
+						}
+						return nil
+					})
+					if want := []claim.Claim{normalized(tt.wantStored)}; !reflect.DeepEqual(gotStored, want) {
+						t.Errorf("stored claims = %+v, want %+v", gotStored, want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestClaimRepo_GetHistory(t *testing.T) {
+	original := conformanceClaim("http://daily.com/a", claim.KindFactCheck, claim.VerdictFalse, 0)
+	retitled := original
+	retitled.Title = "Retitled"
+	rerated := retitled
+	rerated.Verdict = claim.VerdictMostlyTrue
+	rerated.IsFact = true
+	rerated.ReviewedAt = original.ReviewedAt.Add(time.Hour)
+
+	for name, newClaimRepo := range claimRepoImplementations {
+		t.Run(name, func(t *testing.T) {
+			claims := newClaimRepo(t)
+			saveAll(t, claims, original, conformanceClaim("http://daily.com/other", claim.KindNews, claim.VerdictTrue, 0))
+			for _, revision := range []claim.Claim{retitled, retitled, rerated} {
+				if _, err := claims.Save(revision, UpdateStored); err != nil {
+					t.Fatalf("ClaimRepo.Save() error = %v", err)
+				}
+			}
+			var id string
+			claims.Export(ExportFilter{}, func(c claim.Claim) error {
+				if c.URL == original.URL {
+					id = c.ID
+				}
+				return nil
+			})
+
+			got, err := claims.GetHistory(id)
+			if err != nil {
+				t.Fatalf("ClaimRepo.GetHistory() error = %v", err)
+			}
+			if got.Claim.ID != id || !reflect.DeepEqual(normalized(got.Claim), normalized(rerated)) {
+				t.Errorf("ClaimRepo.GetHistory() claim = %+v, want %+v", got.Claim, rerated)
+			}
+			var gotRevisions []ClaimRevision
+			for _, revision := range got.Revisions {
+				if revision.RevisedAt.IsZero() {
+					t.Errorf("ClaimRepo.GetHistory() returned a revision without RevisedAt")
+				}
+				revision.ReviewedAt = revision.ReviewedAt.UTC()
+				revision.RevisedAt = time.Time{}
+				gotRevisions = append(gotRevisions, revision)
+			}
+			wantRevisions := []ClaimRevision{
+				{Title: retitled.Title, Verdict: retitled.Verdict, IsFact: retitled.IsFact, ReviewedAt: retitled.ReviewedAt},
+				{Title: original.Title, Verdict: original.Verdict, IsFact: original.IsFact, ReviewedAt: original.ReviewedAt},
+			}
+			if !reflect.DeepEqual(gotRevisions, wantRevisions) {
+				t.Errorf("ClaimRepo.GetHistory() revisions = %+v, want %+v", gotRevisions, wantRevisions)
+			}
+
+			for _, unknownID := range []string{"6ba7b810-9dad-11d1-80b4-00c04fd430c8", "not-an-id"} {
+				if _, err := claims.GetHistory(unknownID); !IsClaimNotFoundError(err) {
+					t.Errorf("ClaimRepo.GetHistory(%v) error = %v, want a claimNotFoundError", unknownID, err)
+				}
+			}
+		})
+	}
+}
+
+func TestClaimRepo_Save_concurrently(t *testing.T) {
+	for name, newClaimRepo := range claimRepoImplementations {
+		t.Run(name, func(t *testing.T) {
+			claims := newClaimRepo(t)
+			results := make(chan SaveResult)
+			for i := 0; i < 10; i++ {
+				go func() {
+					result, err := claims.Save(conformanceClaim("http://daily.com/a", claim.KindNews, claim.VerdictTrue, 0), KeepStored)
+					if err != nil {
+						t.Errorf("ClaimRepo.Save() error = %v", err)
+					}
+					results <- result
+				}()
+			}
+			inserted := 0
+			for i := 0; i < 10; i++ {
+				if <-results == ClaimInserted {
+					inserted++
+				}
+			}
+			if inserted != 1 {
+				t.Errorf("ClaimRepo.Save() inserted the claim %v times, want 1", inserted)
+			}
+		})
+	}
+}
+
+func TestClaimRepo_SaveBatch(t *testing.T) {
+	stored := conformanceClaim("http://daily.com/stored", claim.KindNews, claim.VerdictTrue, 0)
+	revised := stored
+	revised.Title = "Revised title"
+	batch := []claim.Claim{
+		conformanceClaim("http://daily.com/new", claim.KindNews, claim.VerdictTrue, 1),
+		revised,
+		conformanceClaim("http://daily.com/new", claim.KindNews, claim.VerdictTrue, 1),
+	}
+	for name, newClaimRepo := range claimRepoImplementations {
+		t.Run(name, func(t *testing.T) {
+			claims := newClaimRepo(t)
+			saveAll(t, claims, stored)
+
+			got, err := claims.SaveBatch(batch, UpdateStored)
+			if err != nil {
+				t.Fatalf("ClaimRepo.SaveBatch() error = %v", err)
+			}
+			if want := []SaveResult{ClaimInserted, ClaimUpdated, ClaimExisted}; !reflect.DeepEqual(got, want) {
+				t.Errorf("ClaimRepo.SaveBatch() = %v, want %v", got, want)
+			}
+			var gotTitles []string
+			claims.Export(ExportFilter{}, func(c claim.Claim) error {
+				gotTitles = append(gotTitles, c.Title)
+				return nil
+			})
+			if want := []string{"Revised title", batch[0].Title}; !reflect.DeepEqual(gotTitles, want) {
+				t.Errorf("stored titles = %v, want %v", gotTitles, want)
+			}
+		})
+	}
+}
+
+func TestClaimRepo_Get(t *testing.T) {
+	var manyClaims []claim.Claim
+	var latestURLs []string
+	for i := 0; i < DefaultPageLimit+5; i++ {
+		c := conformanceClaim(fmt.Sprintf("http://daily.com/%v", i), claim.KindNews, claim.VerdictTrue, i)
+		manyClaims = append(manyClaims, c)
+		if i >= 5 {
+			latestURLs = append([]string{c.URL}, latestURLs...)
+		}
+	}
+	// reviewed between the claims reviewed at hours 1 and 4, in another location
+	shifted := conformanceClaim("http://daily.com/shifted", claim.KindNews, claim.VerdictTrue, 3)
+	shifted.ReviewedAt = shifted.ReviewedAt.In(time.FixedZone("Tokyo", 9*60*60))
+
+	tests := []struct {
+		name    string
+		saved   []claim.Claim
+		query   ClaimQuery
+		wantURL []string
+	}{
+		{
+			name: "returns claims of the requested truthfulness from latest to oldest",
+			saved: []claim.Claim{
+				conformanceClaim("http://daily.com/old", claim.KindNews, claim.VerdictTrue, 0),
+				conformanceClaim("http://daily.com/fake", claim.KindNews, claim.VerdictFalse, 1),
+				conformanceClaim("http://daily.com/new", claim.KindNews, claim.VerdictMostlyTrue, 2),
+			},
+			query:   ClaimQuery{IsFact: true, ReviewedBefore: conformanceTime.Add(time.Hour * 24)},
+			wantURL: []string{"http://daily.com/new", "http://daily.com/old"},
+		},
+		{
+			name: "only returns claims reviewed before ReviewedBefore",
+			saved: []claim.Claim{
+				conformanceClaim("http://daily.com/0", claim.KindNews, claim.VerdictTrue, 0),
+				conformanceClaim("http://daily.com/1", claim.KindNews, claim.VerdictTrue, 1),
+				conformanceClaim("http://daily.com/2", claim.KindNews, claim.VerdictTrue, 2),
+			},
+			query:   ClaimQuery{IsFact: true, ReviewedBefore: conformanceTime.Add(time.Hour)},
+			wantURL: []string{"http://daily.com/0"},
+		},
+		{
+			name: "filters by kind",
+			saved: []claim.Claim{
+				conformanceClaim("http://daily.com/news", claim.KindNews, claim.VerdictFalse, 0),
+				conformanceClaim("http://daily.com/satire", claim.KindSatire, claim.VerdictSatire, 1),
+				conformanceClaim("http://daily.com/check", claim.KindFactCheck, claim.VerdictFalse, 2),
+			},
+			query:   ClaimQuery{IsFact: false, ReviewedBefore: conformanceTime.Add(time.Hour * 24), Kinds: []claim.Kind{claim.KindNews, claim.KindFactCheck}},
+			wantURL: []string{"http://daily.com/check", "http://daily.com/news"},
+		},
+		{
+			name:    "returns at most one page of claims",
+			saved:   manyClaims,
+			query:   ClaimQuery{IsFact: true, ReviewedBefore: conformanceTime.Add(time.Hour * 1000)},
+			wantURL: latestURLs,
+		},
+		{
+			name:    "returns at most Limit claims",
+			saved:   manyClaims,
+			query:   ClaimQuery{IsFact: true, ReviewedBefore: conformanceTime.Add(time.Hour * 1000), Limit: 3},
+			wantURL: latestURLs[:3],
+		},
+		{
+			name: "compares times in different locations",
+			saved: []claim.Claim{
+				conformanceClaim("http://daily.com/1", claim.KindNews, claim.VerdictTrue, 1),
+				shifted,
+				conformanceClaim("http://daily.com/4", claim.KindNews, claim.VerdictTrue, 4),
+			},
+			query:   ClaimQuery{IsFact: true, ReviewedBefore: conformanceTime.Add(time.Hour * 4).In(time.FixedZone("Lima", -5*60*60))},
+			wantURL: []string{"http://daily.com/shifted", "http://daily.com/1"},
+		},
+		{
+			name:    "returns no claims if none match",
+			query:   ClaimQuery{IsFact: true, ReviewedBefore: conformanceTime},
+			wantURL: []string{},
+		},
+	}
+	for name, newClaimRepo := range claimRepoImplementations {
+		newClaimRepo := newClaimRepo
+		t.Run(name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					claims := newClaimRepo(t)
+					saveAll(t, claims, tt.saved...)
+
+					got, err := claims.Get(tt.query)
+					if err != nil {
+						t.Fatalf("ClaimRepo.Get() error = %v", err)
+					}
+					if gotURLs := claimURLs(got); !reflect.DeepEqual(gotURLs, tt.wantURL) {
+						t.Errorf("ClaimRepo.Get() = %v, want %v", gotURLs, tt.wantURL)
+					}
+					for _, c := range got {
+						if c.Reviews != nil {
+							t.Errorf("ClaimRepo.Get() returned reviews %v for a claim saved without any", c.Reviews)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestClaimRepo_Get_cursor(t *testing.T) {
+	saved := []claim.Claim{
+		conformanceClaim("http://daily.com/old", claim.KindNews, claim.VerdictTrue, 0),
+		conformanceClaim("http://daily.com/new", claim.KindNews, claim.VerdictTrue, 2),
+	}
+	// claims reviewed at the same time, which span several pages
+	for i := 0; i < 5; i++ {
+		saved = append(saved, conformanceClaim(fmt.Sprintf("http://daily.com/tie/%v", i), claim.KindNews, claim.VerdictTrue, 1))
+	}
+
+	for name, newClaimRepo := range claimRepoImplementations {
+		t.Run(name, func(t *testing.T) {
+			claims := newClaimRepo(t)
+			saveAll(t, claims, saved...)
+			var want []claim.Claim
+			claims.Export(ExportFilter{}, func(c claim.Claim) error {
+				want = append(want, c)
+				return nil
+			})
+			sort.Slice(want, func(i, j int) bool { return CursorOf(want[i]).Precedes(CursorOf(want[j])) })
+
+			var got []claim.Claim
+			query := ClaimQuery{IsFact: true, ReviewedBefore: conformanceTime.Add(time.Hour * 24), Limit: 2}
+			for pages := 0; pages < len(saved); pages++ {
+				page, err := claims.Get(query)
+				if err != nil {
+					t.Fatalf("ClaimRepo.Get() error = %v", err)
+				}
+				if len(page) > query.Limit {
+					t.Fatalf("ClaimRepo.Get() returned %v claims, want at most %v", len(page), query.Limit)
+				}
+				if len(page) == 0 {
+					break
+				}
+				got = append(got, page...)
+				cursor := CursorOf(page[len(page)-1])
+				query.After = &cursor
+			}
+			if gotURLs, wantURLs := claimURLs(got), claimURLs(want); !reflect.DeepEqual(gotURLs, wantURLs) {
+				t.Errorf("claims of every page = %v, want each claim once in order %v", gotURLs, wantURLs)
+			}
+		})
+	}
+}
+
+func TestClaimRepo_Get_nearDuplicates(t *testing.T) {
+	endorsed := conformanceClaim("http://daily.com/endorsed", claim.KindNews, claim.VerdictTrue, 0)
+	endorsed.Title = "The Pope endorsed Donald Trump for president"
+	unrelated := conformanceClaim("http://daily.com/unrelated", claim.KindNews, claim.VerdictTrue, 1)
+	debunked := conformanceClaim("http://checker.com/endorsed", claim.KindFactCheck, claim.VerdictFalse, 2)
+	debunked.Title = "Pope Francis endorsed Donald Trump for president!"
+
+	tests := []struct {
+		name    string
+		query   ClaimQuery
+		wantURL []string
+	}{
+		{
+			name:    "only returns the latest claim of a cluster whatever its truthfulness",
+			query:   ClaimQuery{IsFact: true, ReviewedBefore: conformanceTime.Add(time.Hour * 24)},
+			wantURL: []string{unrelated.URL},
+		},
+		{
+			name:    "returns the latest claim of a cluster",
+			query:   ClaimQuery{IsFact: false, ReviewedBefore: conformanceTime.Add(time.Hour * 24)},
+			wantURL: []string{debunked.URL},
+		},
+		{
+			name:    "does not return older claims of a cluster whose latest claim is on a previous page",
+			query:   ClaimQuery{IsFact: true, ReviewedBefore: conformanceTime.Add(time.Hour)},
+			wantURL: []string{},
+		},
+		{
+			name:    "returns the latest claim of a cluster of the requested kinds",
+			query:   ClaimQuery{IsFact: true, ReviewedBefore: conformanceTime.Add(time.Hour * 24), Kinds: []claim.Kind{claim.KindNews}},
+			wantURL: []string{unrelated.URL, endorsed.URL},
+		},
+		{
+			name:    "returns every claim of a cluster if requested",
+			query:   ClaimQuery{IsFact: true, ReviewedBefore: conformanceTime.Add(time.Hour * 24), IncludeNearDuplicates: true},
+			wantURL: []string{unrelated.URL, endorsed.URL},
+		},
+	}
+	for name, newClaimRepo := range claimRepoImplementations {
+		newClaimRepo := newClaimRepo
+		t.Run(name, func(t *testing.T) {
+			claims := newClaimRepo(t)
+			saveAll(t, claims, endorsed, unrelated, debunked)
+
+			clusters := make(map[string]string)
+			claims.Export(ExportFilter{}, func(c claim.Claim) error {
+				clusters[c.URL] = c.ClusterID
+				return nil
+			})
+			if clusters[endorsed.URL] == "" || clusters[endorsed.URL] != clusters[debunked.URL] || clusters[endorsed.URL] == clusters[unrelated.URL] {
+				t.Errorf("claim clusters = %v, want the endorsement claims in the same cluster", clusters)
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got, err := claims.Get(tt.query)
+					if err != nil {
+						t.Fatalf("ClaimRepo.Get() error = %v", err)
+					}
+					if gotURLs := claimURLs(got); !reflect.DeepEqual(gotURLs, tt.wantURL) {
+						t.Errorf("ClaimRepo.Get() = %v, want %v", gotURLs, tt.wantURL)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestClaimRepo_Export(t *testing.T) {
+	isFact := true
+	saved := []claim.Claim{
+		conformanceClaim("http://daily.com/2", claim.KindNews, claim.VerdictTrue, 2),
+		conformanceClaim("http://daily.com/0", claim.KindNews, claim.VerdictFalse, 0),
+		conformanceClaim("http://daily.com/1", claim.KindSatire, claim.VerdictSatire, 1),
+		conformanceClaim("http://daily.com/3", claim.KindFactCheck, claim.VerdictTrue, 3),
+	}
+	saved[3].PublisherName = "Checker"
+
+	tests := []struct {
+		name    string
+		filter  ExportFilter
+		wantURL []string
+	}{
+		{
+			name:    "exports every claim from oldest to latest without a filter",
+			wantURL: []string{"http://daily.com/0", "http://daily.com/1", "http://daily.com/2", "http://daily.com/3"},
+		},
+		{
+			name:    "filters by truthfulness",
+			filter:  ExportFilter{IsFact: &isFact},
+			wantURL: []string{"http://daily.com/2", "http://daily.com/3"},
+		},
+		{
+			name:    "filters by kind",
+			filter:  ExportFilter{Kinds: []claim.Kind{claim.KindSatire, claim.KindFactCheck}},
+			wantURL: []string{"http://daily.com/1", "http://daily.com/3"},
+		},
+		{
+			name:    "filters by publisher",
+			filter:  ExportFilter{Publishers: []string{"Checker"}},
+			wantURL: []string{"http://daily.com/3"},
+		},
+		{
+			name:    "filters by review time",
+			filter:  ExportFilter{ReviewedAfter: conformanceTime.Add(time.Hour), ReviewedBefore: conformanceTime.Add(3 * time.Hour)},
+			wantURL: []string{"http://daily.com/1", "http://daily.com/2"},
+		},
+	}
+	for name, newClaimRepo := range claimRepoImplementations {
+		newClaimRepo := newClaimRepo
+		t.Run(name, func(t *testing.T) {
+			claims := newClaimRepo(t)
+			saveAll(t, claims, saved...)
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					var got []claim.Claim
+					err := claims.Export(tt.filter, func(c claim.Claim) error {
+						got = append(got, c)
+						return nil
+					})
+					if err != nil {
+						t.Fatalf("ClaimRepo.Export() error = %v", err)
+					}
+					if gotURLs := claimURLs(got); !reflect.DeepEqual(gotURLs, tt.wantURL) {
+						t.Errorf("ClaimRepo.Export() = %v, want %v", gotURLs, tt.wantURL)
+					}
+				})
+			}
+
+			t.Run("stops at the first error of the callback", func(t *testing.T) {
+				stop := errors.New("stop")
+				exported := 0
+				err := claims.Export(ExportFilter{}, func(c claim.Claim) error {
+					exported++
+					return stop
+				})
+				if err != stop || exported != 1 {
+					t.Errorf("ClaimRepo.Export() error = %v after %v claims, want %v after 1 claim", err, exported, stop)
+				}
+			})
+		})
+	}
+}
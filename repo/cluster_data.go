@@ -0,0 +1,118 @@
+package repo
+
+import (
+	"fake-or-fact/claim"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// TitleBandData is a band of the title of a claim, claims sharing a band are candidate near duplicates
+type TitleBandData struct {
+	Hash    int64     `gorm:"column:hash;primary_key;auto_increment:false"`
+	ClaimID uuid.UUID `gorm:"column:claim_id;primary_key;index:claim_title_band_claim_id_ix"`
+}
+
+const titleBandTableName string = "claim_title_band"
+
+func (TitleBandData) TableName() string {
+	return titleBandTableName
+}
+
+// clusterClaim adds a newly inserted claim to the cluster of the stored claim whose title is the most similar to its title,
+// if it is a near duplicate, and indexes the claim's title bands. The claim is left in its own cluster otherwise
+func clusterClaim(tx *gorm.DB, claimData ClaimData) error {
+	bands := claim.TitleBands(claimData.Title)
+	if len(bands) == 0 {
+		return nil
+	}
+	candidates := make([]ClaimData, 0)
+	err := tx.Select("DISTINCT claim.id, claim.title, claim.cluster_id").
+		Joins("JOIN claim_title_band ON claim_title_band.claim_id = claim.id").
+		Where("claim_title_band.hash IN (?) AND claim.id <> ?", bands, claimData.ID).
+		Find(&candidates).Error
+	if err != nil {
+		return err
+	}
+	if clusterID := nearestCluster(claimData.Title, candidates); clusterID != nil {
+		if err := tx.Model(&ClaimData{}).Where("id = ?", claimData.ID).UpdateColumn("cluster_id", *clusterID).Error; err != nil {
+			return err
+		}
+	}
+	return insertTitleBands(tx, claimData.ID, bands)
+}
+
+// nearestCluster returns the cluster of the candidate whose title is the most similar to the given title,
+// or nil if no candidate is a near duplicate
+func nearestCluster(title string, candidates []ClaimData) *uuid.UUID {
+	var nearest *uuid.UUID
+	highestSimilarity := 0.0
+	for _, candidate := range candidates {
+		similarity := claim.TitleSimilarity(title, candidate.Title)
+		if similarity >= claim.NearDuplicateSimilarity && similarity > highestSimilarity && candidate.ClusterID != nil {
+			nearest = candidate.ClusterID
+			highestSimilarity = similarity
+		}
+	}
+	return nearest
+}
+
+// replaceTitleBands indexes the bands of the revised title of a claim instead of the bands of its previous title
+func replaceTitleBands(tx *gorm.DB, claimID uuid.UUID, title string) error {
+	if err := tx.Where("claim_id = ?", claimID).Delete(&TitleBandData{}).Error; err != nil {
+		return err
+	}
+	return insertTitleBands(tx, claimID, claim.TitleBands(title))
+}
+
+// insertTitleBands inserts the bands of a claim in a single statement
+func insertTitleBands(tx *gorm.DB, claimID uuid.UUID, bands []int64) error {
+	unique := make(map[int64]bool, len(bands))
+	placeholders := make([]string, 0, len(bands))
+	values := make([]interface{}, 0, 2*len(bands))
+	for _, band := range bands {
+		if !unique[band] {
+			unique[band] = true
+			placeholders = append(placeholders, "(?, ?)")
+			values = append(values, band, claimID)
+		}
+	}
+	if len(placeholders) == 0 {
+		return nil
+	}
+	return tx.Exec(fmt.Sprintf("INSERT INTO %v (hash, claim_id) VALUES %v", titleBandTableName, strings.Join(placeholders, ", ")), values...).Error
+}
+
+// RebuildClusters groups every stored claim with its near duplicates, from oldest to latest as if they were collected in that order,
+// and indexes their title bands. It groups the claims stored before clusters existed, and is run in a single transaction.
+// Returns the number of claims which were added to the cluster of an older claim
+func RebuildClusters(db *gorm.DB) (int, error) {
+	grouped := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&TitleBandData{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("UPDATE claim SET cluster_id = id").Error; err != nil {
+			return err
+		}
+		ordered := tx.Select("id, title").Order("reviewed_at ASC, id ASC").Limit(exportBatchSize)
+		for offset := 0; ; offset += exportBatchSize {
+			batch := make([]ClaimData, 0, exportBatchSize)
+			if err := ordered.Offset(offset).Find(&batch).Error; err != nil {
+				return err
+			}
+			for _, claimData := range batch {
+				if err := clusterClaim(tx, claimData); err != nil {
+					return fmt.Errorf("Failed to cluster the claim '%v': %v", claimData.ID, err)
+				}
+			}
+			if len(batch) < exportBatchSize {
+				break
+			}
+		}
+		return tx.Model(&ClaimData{}).Where("cluster_id <> id").Count(&grouped).Error
+	})
+	return grouped, err
+}
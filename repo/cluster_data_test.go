@@ -0,0 +1,50 @@
+package repo
+
+import (
+	"fake-or-fact/claim"
+	"testing"
+)
+
+func TestRebuildClusters(t *testing.T) {
+	db := openTestDB(t)
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	endorsed := conformanceClaim("http://daily.com/endorsed", claim.KindNews, claim.VerdictTrue, 0)
+	endorsed.Title = "The Pope endorsed Donald Trump for president"
+	debunked := conformanceClaim("http://checker.com/endorsed", claim.KindFactCheck, claim.VerdictFalse, 2)
+	debunked.Title = "Pope Francis endorsed Donald Trump for president"
+	unrelated := conformanceClaim("http://daily.com/unrelated", claim.KindNews, claim.VerdictTrue, 1)
+	claims := NewClaimRepo(db)
+	saveAll(t, claims, endorsed, debunked, unrelated)
+	// claims stored before clusters existed are in their own cluster and have no title bands
+	if err := db.Exec("UPDATE claim SET cluster_id = id; DELETE FROM claim_title_band;").Error; err != nil {
+		t.Fatalf("Failed to reset clusters: %v", err)
+	}
+
+	grouped, err := RebuildClusters(db)
+	if err != nil {
+		t.Fatalf("RebuildClusters() error = %v", err)
+	}
+	if grouped != 1 {
+		t.Errorf("RebuildClusters() = %v, want 1", grouped)
+	}
+	clusters := make(map[string]string)
+	claims.Export(ExportFilter{}, func(c claim.Claim) error {
+		clusters[c.URL] = c.ClusterID
+		return nil
+	})
+	if clusters[endorsed.URL] != clusters[debunked.URL] || clusters[endorsed.URL] == clusters[unrelated.URL] {
+		t.Errorf("claim clusters = %v, want the endorsement claims in the same cluster", clusters)
+	}
+
+	// the rebuilt title bands find the near duplicates of new claims
+	quoted := conformanceClaim("http://other.com/endorsed", claim.KindFactCheck, claim.VerdictFalse, 3)
+	quoted.Title = "The Pope endorsed Donald Trump for president?"
+	saveAll(t, claims, quoted)
+	var stored ClaimData
+	db.Where("url = ?", quoted.URL).First(&stored)
+	if stored.ClusterID == nil || stored.ClusterID.String() != clusters[endorsed.URL] {
+		t.Errorf("new claim cluster = %v, want %v", stored.ClusterID, clusters[endorsed.URL])
+	}
+}
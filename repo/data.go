@@ -10,12 +10,24 @@ import (
 )
 
 type ClaimData struct {
-	ID            uuid.UUID `gorm:"column:id;primary_key"`
-	Title         string    `gorm:"column:title;type:varchar(500);not null"`
-	PublisherName string    `gorm:"column:publisher_name;type:varchar(50);not null"`
-	URL           string    `gorm:"column:url;type:varchar(500);unique;not null"`
-	IsFact        bool      `gorm:"column:is_fact;not null;index:is_fact_and_reviewed_at_ix"`
-	ReviewedAt    time.Time `gorm:"column:reviewed_at;not null;index:is_fact_and_reviewed_at_ix"`
+	ID            uuid.UUID    `gorm:"column:id;primary_key"`
+	Title         string       `gorm:"column:title;type:varchar(500);not null"`
+	PublisherName string       `gorm:"column:publisher_name;type:varchar(200);not null"`
+	URL           string       `gorm:"column:url;type:varchar(500);unique;not null"`
+	Kind          string       `gorm:"column:kind;type:varchar(20);not null;default:''"`
+	Verdict       string       `gorm:"column:verdict;type:varchar(20);not null;default:''"`
+	IsFact        bool         `gorm:"column:is_fact;not null;index:is_fact_and_reviewed_at_ix"`
+	ReviewedAt    time.Time    `gorm:"column:reviewed_at;not null;index:is_fact_and_reviewed_at_ix"`
+	Claimant      string       `gorm:"column:claimant;type:varchar(200);not null;default:''"`
+	ClaimDate     *time.Time   `gorm:"column:claim_date"`
+	AppearanceURL string       `gorm:"column:appearance_url;type:varchar(2000);not null;default:''"`
+	Reviews       []ReviewData `gorm:"foreignkey:ClaimID"`
+	// the publisher named PublisherName, nil if it is not a stored publisher
+	PublisherID *uuid.UUID `gorm:"column:publisher_id;index:claim_publisher_id_ix"`
+	// the ID of the oldest claim of the near duplicates of this claim, its own ID if it has none. Claims stored before clusters are their own cluster
+	ClusterID *uuid.UUID `gorm:"column:cluster_id;index:claim_cluster_id_ix"`
+	// the URL the claim was collected with before it was canonicalized, empty if it was stored with its original URL
+	OriginalURL string `gorm:"column:original_url;type:varchar(2000);not null;default:''"`
 }
 
 const claimTableName string = "claim"
@@ -24,9 +36,97 @@ func (ClaimData) TableName() string {
 	return claimTableName
 }
 
+// ReviewData is the review of a claim by a fact-checking publisher
+type ReviewData struct {
+	ID            uuid.UUID `gorm:"column:id;primary_key"`
+	ClaimID       uuid.UUID `gorm:"column:claim_id;not null;index:claim_review_claim_id_ix"`
+	PublisherName string    `gorm:"column:publisher_name;type:varchar(200);not null"`
+	PublisherSite string    `gorm:"column:publisher_site;type:varchar(200);not null"`
+	URL           string    `gorm:"column:url;type:varchar(500);not null"`
+	Title         string    `gorm:"column:title;type:varchar(500);not null"`
+	TextualRating string    `gorm:"column:textual_rating;type:varchar(200);not null"`
+	Verdict       string    `gorm:"column:verdict;type:varchar(20);not null"`
+	LanguageCode  string    `gorm:"column:language_code;type:varchar(20);not null"`
+	ReviewedAt    time.Time `gorm:"column:reviewed_at;not null"`
+}
+
+const reviewTableName string = "claim_review"
+
+func (ReviewData) TableName() string {
+	return reviewTableName
+}
+
 type ClaimRepo interface {
-	Save(claim claim.Claim) error
-	Get(isFact bool, reviewedBefore time.Time) ([]claim.Claim, error)
+	Save(claim claim.Claim, onConflict OnConflict) (SaveResult, error)
+	SaveBatch(claims []claim.Claim, onConflict OnConflict) ([]SaveResult, error)
+	Get(query ClaimQuery) ([]claim.Claim, error)
+	Export(filter ExportFilter, each func(claim.Claim) error) error
+	GetHistory(id string) (ClaimHistory, error)
+}
+
+// OnConflict decides how a claim is saved when a claim with the same URL is already stored
+type OnConflict int
+
+const (
+	// leaves the stored claim unchanged
+	KeepStored OnConflict = iota
+	// updates the title, verdict and review date of the stored claim if they changed, e.g. when a publisher revises its review.
+	// The replaced values are recorded as a revision of the claim
+	UpdateStored
+)
+
+// SaveResult tells how a claim was saved
+type SaveResult int
+
+const (
+	// the claim was not stored yet and was inserted
+	ClaimInserted SaveResult = iota
+	// a claim with the same URL was already stored and was left unchanged
+	ClaimExisted
+	// a claim with the same URL was already stored and its title, verdict or review date were updated
+	ClaimUpdated
+)
+
+func (result SaveResult) String() string {
+	switch result {
+	case ClaimInserted:
+		return "inserted"
+	case ClaimExisted:
+		return "existed"
+	case ClaimUpdated:
+		return "updated"
+	}
+	return fmt.Sprintf("SaveResult(%d)", int(result))
+}
+
+// ClaimQuery filters the claims returned by ClaimRepo.Get
+type ClaimQuery struct {
+	// whether real (IsFact=true) or fake (IsFact=false) claims are returned
+	IsFact bool
+	// only claims reviewed at a time t < ReviewedBefore are returned
+	ReviewedBefore time.Time
+	// only claims returned after the claim at the cursor are returned, e.g. the cursor of the last claim of the previous page. Ignored if nil
+	After *ClaimCursor
+	// the maximum number of claims returned, see PageLimit
+	Limit int
+	// only claims of one of these kinds are returned, claims of any kind are returned if empty
+	Kinds []claim.Kind
+	// whether every claim of a cluster of near duplicates is returned,
+	// instead of only its latest claim of one of the Kinds whatever its truthfulness
+	IncludeNearDuplicates bool
+}
+
+// ExportFilter filters the claims passed to the callback of ClaimRepo.Export. Zero fields do not filter
+type ExportFilter struct {
+	// only real (true) or fake (false) claims are exported, every claim is exported if nil
+	IsFact *bool
+	// only claims of one of these kinds are exported
+	Kinds []claim.Kind
+	// only claims with one of these publisher names are exported
+	Publishers []string
+	// only claims reviewed at a time ReviewedAfter <= t < ReviewedBefore are exported
+	ReviewedAfter  time.Time
+	ReviewedBefore time.Time
 }
 
 type pgClaimRepo struct {
@@ -37,76 +137,339 @@ func NewClaimRepo(db *gorm.DB) ClaimRepo {
 	return &pgClaimRepo{db}
 }
 
-// Save tries to persist a claim and returns an error if it fails.
-// This might be due to a claim with the same URL already being stored.
-func (repo *pgClaimRepo) Save(claim claim.Claim) error {
+// inserts a claim in its own cluster unless a claim with the same URL is stored, linking it to the stored publisher with the same name if any
+const insertClaim = `INSERT INTO claim
+	(id, title, publisher_name, url, original_url, kind, verdict, is_fact, reviewed_at, claimant, claim_date, appearance_url, publisher_id, cluster_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, (SELECT id FROM publisher WHERE name = ?), ?)
+	ON CONFLICT (url) DO NOTHING`
+
+// Save persists a claim along with its reviews in a single transaction, unless a claim with the same URL is already stored.
+// Inserted claims join the cluster of the stored claim whose title is the most similar if it is a near duplicate.
+// In that case the stored claim is left unchanged or updated depending on onConflict, and its reviews are not modified.
+// Claims with the same URL saved concurrently are only inserted once.
+func (repo *pgClaimRepo) Save(claim claim.Claim, onConflict OnConflict) (SaveResult, error) {
+	var result SaveResult
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = saveClaim(tx, claim, onConflict)
+		return err
+	})
+	return result, err
+}
+
+// SaveBatch is Save for several claims, which are all saved in a single transaction.
+// The results are returned in the order of the claims. If any claim fails to be saved, none of them is saved.
+func (repo *pgClaimRepo) SaveBatch(claims []claim.Claim, onConflict OnConflict) ([]SaveResult, error) {
+	results := make([]SaveResult, 0, len(claims))
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		for _, c := range claims {
+			result, err := saveClaim(tx, c, onConflict)
+			if err != nil {
+				return fmt.Errorf("Failed to save the claim '%v': %v", c.URL, err)
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func saveClaim(tx *gorm.DB, claim claim.Claim, onConflict OnConflict) (SaveResult, error) {
 	claimData := asClaimData(claim)
-	existingClaimWithSameURL := new(ClaimData)
-	queryErr := repo.db.Where("url = ?", claimData.URL).First(existingClaimWithSameURL).Error
-	if queryErr == nil {
-		return claimExistsError{*existingClaimWithSameURL}
-	} else {
-		if gorm.IsRecordNotFoundError(queryErr) {
-			return repo.db.Create(&claimData).Error
-		} else {
-			return queryErr
+	inserted := tx.Exec(insertClaim,
+		claimData.ID, claimData.Title, claimData.PublisherName, claimData.URL, claimData.OriginalURL, claimData.Kind, claimData.Verdict, claimData.IsFact,
+		claimData.ReviewedAt, claimData.Claimant, claimData.ClaimDate, claimData.AppearanceURL, claimData.PublisherName, claimData.ID)
+	if inserted.Error != nil {
+		return 0, inserted.Error
+	}
+	if inserted.RowsAffected == 0 {
+		if onConflict != UpdateStored {
+			return ClaimExisted, nil
+		}
+		return reviseClaim(tx, claimData)
+	}
+	for i := range claimData.Reviews {
+		if err := tx.Create(&claimData.Reviews[i]).Error; err != nil {
+			return 0, err
+		}
+	}
+	if err := clusterClaim(tx, claimData); err != nil {
+		return 0, err
+	}
+	return ClaimInserted, nil
+}
 
+// reviseClaim updates the stored claim with the same URL if the given claim revises it, recording its previous values.
+// On postgres the stored claim is locked until the end of the transaction so that concurrent revisions are recorded one after another.
+func reviseClaim(tx *gorm.DB, claimData ClaimData) (SaveResult, error) {
+	stored := new(ClaimData)
+	query := tx
+	if tx.Dialect().GetName() == "postgres" {
+		query = tx.Set("gorm:query_option", "FOR UPDATE")
+	}
+	if err := query.Where("url = ?", claimData.URL).First(stored).Error; err != nil {
+		return 0, err
+	}
+	return revise(tx, stored, claimData, time.Now())
+}
+
+// revise updates the stored claim with the title, verdict and review date of the given claim if they differ,
+// recording its previous values as replaced at revisedAt. The reviews of the given claim replace the stored reviews with the same URL
+// and the other ones are added
+func revise(tx *gorm.DB, stored *ClaimData, claimData ClaimData, revisedAt time.Time) (SaveResult, error) {
+	if !isRevised(*stored, claimData) {
+		return ClaimExisted, nil
+	}
+	revision := asClaimRevisionData(*stored, revisedAt)
+	if err := tx.Create(&revision).Error; err != nil {
+		return 0, err
+	}
+	err := tx.Model(stored).UpdateColumns(map[string]interface{}{
+		"title":       claimData.Title,
+		"verdict":     claimData.Verdict,
+		"is_fact":     claimData.IsFact,
+		"reviewed_at": claimData.ReviewedAt,
+	}).Error
+	if err != nil {
+		return 0, err
+	}
+	if stored.Title != claimData.Title {
+		if err := replaceTitleBands(tx, stored.ID, claimData.Title); err != nil {
+			return 0, err
 		}
 	}
+	for _, review := range claimData.Reviews {
+		if err := reviseReview(tx, stored.ID, review); err != nil {
+			return 0, err
+		}
+	}
+	stored.Title, stored.Verdict, stored.IsFact, stored.ReviewedAt = claimData.Title, claimData.Verdict, claimData.IsFact, claimData.ReviewedAt
+	return ClaimUpdated, nil
 }
 
-const pageLimit = 20
+// reviseReview updates the review of the stored claim with the same URL as the given review, or adds the review if there is none
+func reviseReview(tx *gorm.DB, claimID uuid.UUID, review ReviewData) error {
+	updated := tx.Model(&ReviewData{}).Where("claim_id = ? AND url = ?", claimID, review.URL).UpdateColumns(map[string]interface{}{
+		"publisher_name": review.PublisherName,
+		"publisher_site": review.PublisherSite,
+		"title":          review.Title,
+		"textual_rating": review.TextualRating,
+		"verdict":        review.Verdict,
+		"language_code":  review.LanguageCode,
+		"reviewed_at":    review.ReviewedAt,
+	})
+	if updated.Error != nil || updated.RowsAffected > 0 {
+		return updated.Error
+	}
+	review.ClaimID = claimID
+	return tx.Create(&review).Error
+}
 
-// Get returns a list of claims that are either real (isFact=true) or fake (isFact=false) that were reviewed at a time t < reviewedBefore.
-// Claims are returned from latest to oldest, and are limited to 20 claims per request.
+// Get returns a list of claims matching the query.
+// Claims are returned in the order of ClaimCursor along with all of their reviews, and are limited to PageLimit(query.Limit) claims per request.
+// Unless IncludeNearDuplicates is set, a claim is only returned if it is the latest claim of its cluster of one of the Kinds,
+// so that a cluster is returned once whichever page and truthfulness are requested.
 // An error is returned if an unexpected error is encountered while retrieving the claims.
-func (repo *pgClaimRepo) Get(isFact bool, reviewedBefore time.Time) ([]claim.Claim, error) {
-	foundClaimData := make([]ClaimData, 0, pageLimit)
-	err := repo.db.Where("is_fact = ? AND reviewed_at < ?", isFact, reviewedBefore).Order("reviewed_at DESC").Limit(pageLimit).Find(&foundClaimData).Error
+func (repo *pgClaimRepo) Get(query ClaimQuery) ([]claim.Claim, error) {
+	limit := PageLimit(query.Limit)
+	foundClaimData := make([]ClaimData, 0, limit)
+	db := repo.db.Preload("Reviews", orderReviews).Where("is_fact = ? AND reviewed_at < ?", query.IsFact, query.ReviewedBefore.UTC())
+	if query.After != nil {
+		after := query.After.ReviewedAt.UTC()
+		db = db.Where("reviewed_at < ? OR (reviewed_at = ? AND id < ?)", after, after, query.After.ID)
+	}
+	if len(query.Kinds) > 0 {
+		db = db.Where("kind IN (?)", query.Kinds)
+	}
+	if !query.IncludeNearDuplicates {
+		db = whereLatestOfCluster(db, query.Kinds)
+	}
+	err := db.Order("reviewed_at DESC, id DESC").Limit(limit).Find(&foundClaimData).Error
 	if err != nil {
 		return nil, err
 	}
-	mappedClaims := make([]claim.Claim, 0, pageLimit)
+	mappedClaims := make([]claim.Claim, 0, len(foundClaimData))
 	for _, claimData := range foundClaimData {
 		mappedClaims = append(mappedClaims, asClaim(claimData))
 	}
 	return mappedClaims, nil
 }
 
+// whereLatestOfCluster filters out claims which have a later claim of one of the kinds (or of any kind if empty) in their cluster
+func whereLatestOfCluster(db *gorm.DB, kinds []claim.Kind) *gorm.DB {
+	later := `SELECT 1 FROM claim later WHERE later.cluster_id = claim.cluster_id
+		AND (later.reviewed_at > claim.reviewed_at OR (later.reviewed_at = claim.reviewed_at AND later.id > claim.id))`
+	if len(kinds) > 0 {
+		return db.Where("NOT EXISTS ("+later+" AND later.kind IN (?))", kinds)
+	}
+	return db.Where("NOT EXISTS (" + later + ")")
+}
+
+const exportBatchSize = 500
+
+// Export passes every claim matching the filter to each, from oldest to latest along with all of their reviews.
+// Claims are loaded in batches so that the whole table does not need to fit in memory.
+// Stops and returns the error of each or of the database if any.
+func (repo *pgClaimRepo) Export(filter ExportFilter, each func(claim.Claim) error) error {
+	db := repo.db.Preload("Reviews", orderReviews)
+	if filter.IsFact != nil {
+		db = db.Where("is_fact = ?", *filter.IsFact)
+	}
+	if len(filter.Kinds) > 0 {
+		db = db.Where("kind IN (?)", filter.Kinds)
+	}
+	if len(filter.Publishers) > 0 {
+		db = db.Where("publisher_name IN (?)", filter.Publishers)
+	}
+	if !filter.ReviewedAfter.IsZero() {
+		db = db.Where("reviewed_at >= ?", filter.ReviewedAfter.UTC())
+	}
+	if !filter.ReviewedBefore.IsZero() {
+		db = db.Where("reviewed_at < ?", filter.ReviewedBefore.UTC())
+	}
+	db = db.Order("reviewed_at ASC, id ASC").Limit(exportBatchSize)
+	for offset := 0; ; offset += exportBatchSize {
+		batch := make([]ClaimData, 0, exportBatchSize)
+		if err := db.Offset(offset).Find(&batch).Error; err != nil {
+			return err
+		}
+		for _, claimData := range batch {
+			if err := each(asClaim(claimData)); err != nil {
+				return err
+			}
+		}
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+// GetHistory returns the stored claim with the given ID along with its revisions, from latest to oldest.
+// Returns a claimNotFoundError if no claim is stored with this ID.
+func (repo *pgClaimRepo) GetHistory(id string) (ClaimHistory, error) {
+	claimID, err := uuid.FromString(id)
+	if err != nil {
+		return ClaimHistory{}, claimNotFoundError{id}
+	}
+	claimData := new(ClaimData)
+	err = repo.db.Preload("Reviews", orderReviews).Where("id = ?", claimID).First(claimData).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return ClaimHistory{}, claimNotFoundError{id}
+	}
+	if err != nil {
+		return ClaimHistory{}, err
+	}
+	revisionData := make([]ClaimRevisionData, 0)
+	if err := repo.db.Where("claim_id = ?", claimID).Order("revised_at DESC").Find(&revisionData).Error; err != nil {
+		return ClaimHistory{}, err
+	}
+	revisions := make([]ClaimRevision, 0, len(revisionData))
+	for _, revision := range revisionData {
+		revisions = append(revisions, asClaimRevision(revision))
+	}
+	return ClaimHistory{Claim: asClaim(*claimData), Revisions: revisions}, nil
+}
+
+// orders preloaded reviews from oldest to latest
+func orderReviews(db *gorm.DB) *gorm.DB {
+	return db.Order("reviewed_at ASC")
+}
+
 // returns a new ClaimData based on a Claim.
+// Times are stored in UTC since SQLite compares them as text, which only orders times sharing the same offset.
 func asClaimData(claim claim.Claim) ClaimData {
+	claimID := uuid.NewV4()
+	reviews := make([]ReviewData, 0, len(claim.Reviews))
+	for _, review := range claim.Reviews {
+		reviews = append(reviews, ReviewData{
+			ID:            uuid.NewV4(),
+			ClaimID:       claimID,
+			PublisherName: review.PublisherName,
+			PublisherSite: review.PublisherSite,
+			URL:           review.URL,
+			Title:         review.Title,
+			TextualRating: review.TextualRating,
+			Verdict:       string(review.Verdict),
+			LanguageCode:  review.LanguageCode,
+			ReviewedAt:    review.ReviewedAt.UTC(),
+		})
+	}
+	var claimDate *time.Time
+	if !claim.ClaimDate.IsZero() {
+		utcClaimDate := claim.ClaimDate.UTC()
+		claimDate = &utcClaimDate
+	}
 	return ClaimData{
-		ID:            uuid.NewV4(),
+		ID:            claimID,
 		Title:         claim.Title,
 		PublisherName: claim.PublisherName,
 		URL:           claim.URL,
+		OriginalURL:   claim.OriginalURL,
+		Kind:          string(claim.Kind),
+		Verdict:       string(claim.Verdict),
 		IsFact:        claim.IsFact,
-		ReviewedAt:    claim.ReviewedAt,
+		ReviewedAt:    claim.ReviewedAt.UTC(),
+		Claimant:      claim.Claimant,
+		ClaimDate:     claimDate,
+		AppearanceURL: claim.AppearanceURL,
+		Reviews:       reviews,
 	}
 }
 
 // returns a new Claim based on a ClaimData.
 func asClaim(claimData ClaimData) claim.Claim {
+	var reviews []claim.Review
+	for _, reviewData := range claimData.Reviews {
+		reviews = append(reviews, claim.Review{
+			PublisherName: reviewData.PublisherName,
+			PublisherSite: reviewData.PublisherSite,
+			URL:           reviewData.URL,
+			Title:         reviewData.Title,
+			TextualRating: reviewData.TextualRating,
+			Verdict:       claim.Verdict(reviewData.Verdict),
+			LanguageCode:  reviewData.LanguageCode,
+			ReviewedAt:    reviewData.ReviewedAt,
+		})
+	}
+	var claimDate time.Time
+	if claimData.ClaimDate != nil {
+		claimDate = *claimData.ClaimDate
+	}
+	clusterID := ""
+	if claimData.ClusterID != nil {
+		clusterID = claimData.ClusterID.String()
+	}
 	return claim.Claim{
+		ID:            claimData.ID.String(),
+		ClusterID:     clusterID,
 		Title:         claimData.Title,
 		PublisherName: claimData.PublisherName,
 		URL:           claimData.URL,
+		OriginalURL:   claimData.OriginalURL,
+		Kind:          claim.Kind(claimData.Kind),
+		Verdict:       claim.Verdict(claimData.Verdict),
 		IsFact:        claimData.IsFact,
 		ReviewedAt:    claimData.ReviewedAt,
+		Claimant:      claimData.Claimant,
+		ClaimDate:     claimDate,
+		AppearanceURL: claimData.AppearanceURL,
+		Reviews:       reviews,
 	}
 }
 
-// IsClaimExistsError returns true if the given error is of type claimExistsError
-func IsClaimExistsError(err error) bool {
-	_, isClaimExists := err.(claimExistsError)
-	return isClaimExists
+// IsClaimNotFoundError returns true if the given error is of type claimNotFoundError
+func IsClaimNotFoundError(err error) bool {
+	_, isNotFound := err.(claimNotFoundError)
+	return isNotFound
 }
 
-type claimExistsError struct {
-	existingClaim ClaimData
+type claimNotFoundError struct {
+	id string
 }
 
-func (e claimExistsError) Error() string {
-	return fmt.Sprintf("A Claim already exists with the URL '%v': %#v", e.existingClaim.URL, e.existingClaim)
+func (e claimNotFoundError) Error() string {
+	return fmt.Sprintf("No claim is stored with the ID '%v'", e.id)
 }
-
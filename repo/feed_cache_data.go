@@ -0,0 +1,54 @@
+package repo
+
+import (
+	"fake-or-fact/claim"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+type FeedCacheData struct {
+	URL          string    `gorm:"column:url;type:varchar(2000);primary_key"`
+	ETag         string    `gorm:"column:etag;type:varchar(500);not null"`
+	LastModified string    `gorm:"column:last_modified;type:varchar(100);not null"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;not null"`
+}
+
+const feedCacheTableName string = "rss_feed_cache"
+
+func (FeedCacheData) TableName() string {
+	return feedCacheTableName
+}
+
+type pgFeedCache struct {
+	db *gorm.DB
+}
+
+// NewFeedCache creates a claim.FeedCache persisting feed validators in the database
+func NewFeedCache(db *gorm.DB) claim.FeedCache {
+	return &pgFeedCache{db}
+}
+
+// GetValidators returns the validators stored for the feed URL, or empty validators if none are stored
+func (cache *pgFeedCache) GetValidators(feedURL string) (claim.FeedValidators, error) {
+	var feedCacheData FeedCacheData
+	err := cache.db.Where("url = ?", feedURL).First(&feedCacheData).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return claim.FeedValidators{}, nil
+	}
+	if err != nil {
+		return claim.FeedValidators{}, err
+	}
+	return claim.FeedValidators{ETag: feedCacheData.ETag, LastModified: feedCacheData.LastModified}, nil
+}
+
+// SaveValidators stores the validators of the feed URL, replacing the ones previously stored
+func (cache *pgFeedCache) SaveValidators(feedURL string, validators claim.FeedValidators) error {
+	feedCacheData := FeedCacheData{
+		URL:          feedURL,
+		ETag:         validators.ETag,
+		LastModified: validators.LastModified,
+		UpdatedAt:    time.Now(),
+	}
+	return cache.db.Save(&feedCacheData).Error
+}
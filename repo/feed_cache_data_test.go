@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"fake-or-fact/claim"
+	"testing"
+)
+
+func TestFeedCache_SaveAndGetValidators(t *testing.T) {
+	db := openTestDB(t)
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	cache := NewFeedCache(db)
+	const feedURL = "https://daily.com/feed"
+
+	got, err := cache.GetValidators(feedURL)
+	if err != nil || got != (claim.FeedValidators{}) {
+		t.Errorf("GetValidators() of an unknown feed = %+v, %v, want empty validators", got, err)
+	}
+	for _, validators := range []claim.FeedValidators{
+		{ETag: `"v1"`, LastModified: "Fri, 01 May 2020 10:00:00 GMT"},
+		{ETag: `"v2"`},
+	} {
+		if err := cache.SaveValidators(feedURL, validators); err != nil {
+			t.Fatalf("SaveValidators() error = %v", err)
+		}
+		if got, err := cache.GetValidators(feedURL); err != nil || got != validators {
+			t.Errorf("GetValidators() = %+v, %v, want %+v", got, err, validators)
+		}
+	}
+	if got, err := cache.GetValidators("https://other.com/feed"); err != nil || got != (claim.FeedValidators{}) {
+		t.Errorf("GetValidators() of another feed = %+v, %v, want empty validators", got, err)
+	}
+}
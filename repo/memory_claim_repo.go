@@ -0,0 +1,204 @@
+package repo
+
+import (
+	"fake-or-fact/claim"
+	"sort"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type memoryClaimRepo struct {
+	mutex sync.RWMutex
+	// stored claims in the order they were saved
+	claims []ClaimData
+	// revisions of the stored claims in the order they were recorded
+	revisions []ClaimRevisionData
+}
+
+// NewMemoryClaimRepo returns an empty ClaimRepo keeping claims in memory, which behaves like the database-backed ClaimRepo.
+// It is meant for tests and local experiments, claims are lost when the process exits.
+func NewMemoryClaimRepo() ClaimRepo {
+	return &memoryClaimRepo{}
+}
+
+// Save stores a claim unless a claim with the same URL is stored, which is left unchanged or revised depending on onConflict
+func (repo *memoryClaimRepo) Save(claim claim.Claim, onConflict OnConflict) (SaveResult, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	return repo.save(claim, onConflict), nil
+}
+
+// SaveBatch is Save for several claims, returning the results in the order of the claims
+func (repo *memoryClaimRepo) SaveBatch(claims []claim.Claim, onConflict OnConflict) ([]SaveResult, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	results := make([]SaveResult, 0, len(claims))
+	for _, c := range claims {
+		results = append(results, repo.save(c, onConflict))
+	}
+	return results, nil
+}
+
+// save is Save for callers holding the lock
+func (repo *memoryClaimRepo) save(claim claim.Claim, onConflict OnConflict) SaveResult {
+	claimData := asClaimData(claim)
+	for i, stored := range repo.claims {
+		if stored.URL != claimData.URL {
+			continue
+		}
+		if onConflict != UpdateStored || !isRevised(stored, claimData) {
+			return ClaimExisted
+		}
+		repo.revisions = append(repo.revisions, asClaimRevisionData(stored, time.Now()))
+		repo.claims[i].Title = claimData.Title
+		repo.claims[i].Verdict = claimData.Verdict
+		repo.claims[i].IsFact = claimData.IsFact
+		repo.claims[i].ReviewedAt = claimData.ReviewedAt
+		repo.claims[i].Reviews = revisedReviews(stored, claimData.Reviews)
+		return ClaimUpdated
+	}
+	ownCluster := claimData.ID
+	claimData.ClusterID = &ownCluster
+	if clusterID := nearestCluster(claimData.Title, repo.claims); clusterID != nil {
+		claimData.ClusterID = clusterID
+	}
+	repo.claims = append(repo.claims, claimData)
+	return ClaimInserted
+}
+
+// revisedReviews returns the reviews of the stored claim in which the reviews with the same URL as a revised review are replaced by it,
+// followed by the revised reviews which were not stored
+func revisedReviews(stored ClaimData, revised []ReviewData) []ReviewData {
+	reviews := append([]ReviewData(nil), stored.Reviews...)
+	for _, review := range revised {
+		review.ClaimID = stored.ID
+		found := false
+		for i := range reviews {
+			if reviews[i].URL == review.URL {
+				review.ID = reviews[i].ID
+				reviews[i] = review
+				found = true
+			}
+		}
+		if !found {
+			reviews = append(reviews, review)
+		}
+	}
+	return reviews
+}
+
+// Get returns at most PageLimit(query.Limit) claims matching the query in the order of ClaimCursor, only returning the latest claim of every cluster
+// of one of the Kinds unless IncludeNearDuplicates is set
+func (repo *memoryClaimRepo) Get(query ClaimQuery) ([]claim.Claim, error) {
+	isOfKind := func(claimData ClaimData) bool {
+		return len(query.Kinds) == 0 || containsKind(query.Kinds, claim.Kind(claimData.Kind))
+	}
+	latestOfCluster := make(map[uuid.UUID]ClaimData)
+	for _, claimData := range repo.find(isOfKind) {
+		if latest, found := latestOfCluster[*claimData.ClusterID]; !found || cursorOfData(claimData).Precedes(cursorOfData(latest)) {
+			latestOfCluster[*claimData.ClusterID] = claimData
+		}
+	}
+	found := repo.find(func(claimData ClaimData) bool {
+		return claimData.IsFact == query.IsFact &&
+			claimData.ReviewedAt.Before(query.ReviewedBefore) &&
+			(query.After == nil || query.After.Precedes(cursorOfData(claimData))) &&
+			isOfKind(claimData) &&
+			(query.IncludeNearDuplicates || latestOfCluster[*claimData.ClusterID].ID == claimData.ID)
+	})
+	sort.Slice(found, func(i, j int) bool {
+		return cursorOfData(found[i]).Precedes(cursorOfData(found[j]))
+	})
+	if limit := PageLimit(query.Limit); len(found) > limit {
+		found = found[:limit]
+	}
+	mappedClaims := make([]claim.Claim, 0, len(found))
+	for _, claimData := range found {
+		mappedClaims = append(mappedClaims, asClaim(claimData))
+	}
+	return mappedClaims, nil
+}
+
+// Export passes every claim matching the filter to each from oldest to latest, stopping at the first error of each
+func (repo *memoryClaimRepo) Export(filter ExportFilter, each func(claim.Claim) error) error {
+	found := repo.find(func(claimData ClaimData) bool {
+		return (filter.IsFact == nil || claimData.IsFact == *filter.IsFact) &&
+			(len(filter.Kinds) == 0 || containsKind(filter.Kinds, claim.Kind(claimData.Kind))) &&
+			(len(filter.Publishers) == 0 || containsString(filter.Publishers, claimData.PublisherName)) &&
+			(filter.ReviewedAfter.IsZero() || !claimData.ReviewedAt.Before(filter.ReviewedAfter)) &&
+			(filter.ReviewedBefore.IsZero() || claimData.ReviewedAt.Before(filter.ReviewedBefore))
+	})
+	sort.SliceStable(found, func(i, j int) bool {
+		if !found[i].ReviewedAt.Equal(found[j].ReviewedAt) {
+			return found[i].ReviewedAt.Before(found[j].ReviewedAt)
+		}
+		return found[i].ID.String() < found[j].ID.String()
+	})
+	for _, claimData := range found {
+		if err := each(asClaim(claimData)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetHistory returns the stored claim with the given ID along with its revisions, from latest to oldest
+func (repo *memoryClaimRepo) GetHistory(id string) (ClaimHistory, error) {
+	found := repo.find(func(claimData ClaimData) bool {
+		return claimData.ID.String() == id
+	})
+	if len(found) == 0 {
+		return ClaimHistory{}, claimNotFoundError{id}
+	}
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+	revisions := make([]ClaimRevision, 0)
+	for i := len(repo.revisions) - 1; i >= 0; i-- {
+		if repo.revisions[i].ClaimID == found[0].ID {
+			revisions = append(revisions, asClaimRevision(repo.revisions[i]))
+		}
+	}
+	return ClaimHistory{Claim: asClaim(found[0]), Revisions: revisions}, nil
+}
+
+// find returns copies of the stored claims matching the predicate, ordering their reviews from oldest to latest
+func (repo *memoryClaimRepo) find(matches func(ClaimData) bool) []ClaimData {
+	repo.mutex.RLock()
+	defer repo.mutex.RUnlock()
+	found := make([]ClaimData, 0)
+	for _, claimData := range repo.claims {
+		if matches(claimData) {
+			claimData.Reviews = append([]ReviewData(nil), claimData.Reviews...)
+			sort.SliceStable(claimData.Reviews, func(i, j int) bool {
+				return claimData.Reviews[i].ReviewedAt.Before(claimData.Reviews[j].ReviewedAt)
+			})
+			found = append(found, claimData)
+		}
+	}
+	return found
+}
+
+// cursorOfData returns the position of a stored claim
+func cursorOfData(claimData ClaimData) ClaimCursor {
+	return ClaimCursor{ReviewedAt: claimData.ReviewedAt, ID: claimData.ID.String()}
+}
+
+func containsKind(kinds []claim.Kind, kind claim.Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package repo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Migration is a versioned change of the database schema.
+// Its Up statements are applied in a transaction along with its record in the schema_migrations table, Down statements revert them.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	// empty if the migration cannot be reverted
+	Down string
+	// the statements used instead of Up and Down on SQLite, which lacks some of the statements and types used on postgres.
+	// nil if Up and Down also work on SQLite
+	SQLite *MigrationStatements
+}
+
+// MigrationStatements are the statements of a migration specific to a dialect. Empty statements do nothing
+type MigrationStatements struct {
+	Up   string
+	Down string
+}
+
+// statements returns the statements applying and reverting the migration on the given dialect, along with whether it can be reverted
+func (migration Migration) statements(dialect string) (up string, down string, reversible bool) {
+	if dialect == "sqlite3" && migration.SQLite != nil {
+		return migration.SQLite.Up, migration.SQLite.Down, true
+	}
+	return migration.Up, migration.Down, migration.Down != ""
+}
+
+type SchemaMigrationData struct {
+	Version   int       `gorm:"column:version;primary_key;auto_increment:false"`
+	Name      string    `gorm:"column:name;type:varchar(200);not null"`
+	AppliedAt time.Time `gorm:"column:applied_at;not null"`
+}
+
+const schemaMigrationTableName string = "schema_migrations"
+
+func (SchemaMigrationData) TableName() string {
+	return schemaMigrationTableName
+}
+
+const createSchemaMigrationTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version integer PRIMARY KEY,
+	name varchar(200) NOT NULL,
+	applied_at %v NOT NULL
+)`
+
+// identifies the advisory lock held by postgres transactions applying a migration, so that concurrently starting servers
+// do not apply the same migration twice
+const migrationLockID = 2718281828
+
+// MigrationStatus tells whether a migration was applied
+type MigrationStatus struct {
+	Version int
+	Name    string
+	// nil if the migration was not applied
+	AppliedAt *time.Time
+}
+
+// LatestVersion returns the version of the latest migration, which is the schema version expected by the repositories
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrate applies every migration which was not applied yet
+func Migrate(db *gorm.DB) error {
+	return MigrateTo(db, LatestVersion())
+}
+
+// MigrateTo applies the migrations up to the given version which were not applied yet,
+// then reverts the applied migrations above the given version from latest to oldest. Version 0 reverts every migration.
+func MigrateTo(db *gorm.DB, version int) error {
+	return migrateTo(db, migrations, version)
+}
+
+// GetMigrationStatus returns the status of every migration, from oldest to latest
+func GetMigrationStatus(db *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedMigration, found := applied[migration.Version]; found {
+			status.AppliedAt = &appliedMigration.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func migrateTo(db *gorm.DB, migrations []Migration, version int) error {
+	if version < 0 || version > migrations[len(migrations)-1].Version {
+		return fmt.Errorf("Unknown schema version %v, expected a version between 0 and %v", version, migrations[len(migrations)-1].Version)
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+	for _, migration := range migrations {
+		if _, isApplied := applied[migration.Version]; !isApplied && migration.Version <= version {
+			if err := apply(db, migration); err != nil {
+				return err
+			}
+		}
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if _, isApplied := applied[migration.Version]; isApplied && migration.Version > version {
+			if err := revert(db, migration); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// appliedMigrations returns the applied migrations keyed by version, creating the schema_migrations table if needed
+func appliedMigrations(db *gorm.DB) (map[int]SchemaMigrationData, error) {
+	timestampType := "timestamp with time zone"
+	// the SQLite driver only parses columns declared as timestamp as times
+	if db.Dialect().GetName() == "sqlite3" {
+		timestampType = "timestamp"
+	}
+	if err := db.Exec(fmt.Sprintf(createSchemaMigrationTable, timestampType)).Error; err != nil {
+		return nil, fmt.Errorf("Failed to create the schema_migrations table: %v", err)
+	}
+	var appliedData []SchemaMigrationData
+	if err := db.Find(&appliedData).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]SchemaMigrationData, len(appliedData))
+	for _, migrationData := range appliedData {
+		applied[migrationData.Version] = migrationData
+	}
+	return applied, nil
+}
+
+// apply runs the Up statements of a migration and records it, unless another process applied it concurrently
+func apply(db *gorm.DB, migration Migration) error {
+	err := inMigrationTransaction(db, func(tx *gorm.DB) error {
+		isApplied, err := isApplied(tx, migration.Version)
+		if err != nil || isApplied {
+			return err
+		}
+		if up, _, _ := migration.statements(tx.Dialect().GetName()); up != "" {
+			if err := tx.Exec(up).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&SchemaMigrationData{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to apply migration %v_%v: %v", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// revert runs the Down statements of a migration and deletes its record, unless another process reverted it concurrently
+func revert(db *gorm.DB, migration Migration) error {
+	_, down, reversible := migration.statements(db.Dialect().GetName())
+	if !reversible {
+		return fmt.Errorf("Migration %v_%v cannot be reverted", migration.Version, migration.Name)
+	}
+	err := inMigrationTransaction(db, func(tx *gorm.DB) error {
+		isApplied, err := isApplied(tx, migration.Version)
+		if err != nil || !isApplied {
+			return err
+		}
+		if down != "" {
+			if err := tx.Exec(down).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("version = ?", migration.Version).Delete(&SchemaMigrationData{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to revert migration %v_%v: %v", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// inMigrationTransaction runs f in a transaction, holding the migration lock on postgres
+func inMigrationTransaction(db *gorm.DB, f func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if tx.Dialect().GetName() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+				return err
+			}
+		}
+		return f(tx)
+	})
+}
+
+func isApplied(tx *gorm.DB, version int) (bool, error) {
+	count := 0
+	err := tx.Model(&SchemaMigrationData{}).Where("version = ?", version).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,218 @@
+package repo
+
+import (
+	"fake-or-fact/claim"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+func TestMigrations(t *testing.T) {
+	names := make(map[string]bool)
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("Migration %v has version %v, want %v", migration.Name, migration.Version, i+1)
+		}
+		if migration.Name == "" || names[migration.Name] {
+			t.Errorf("Migration %v has an empty or duplicate name '%v'", migration.Version, migration.Name)
+		}
+		names[migration.Name] = true
+		if migration.Up == "" || migration.Down == "" {
+			t.Errorf("Migration %v_%v is missing its Up or Down statements", migration.Version, migration.Name)
+		}
+	}
+}
+
+// openTestDB opens an empty in-memory SQLite database, closed at the end of the test
+func openTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	// every connection to :memory: opens a different database
+	db.DB().SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "create_a", Up: "CREATE TABLE a (id integer PRIMARY KEY);", Down: "DROP TABLE a;"},
+	{Version: 2, Name: "create_b", Up: "CREATE TABLE b (id integer PRIMARY KEY); INSERT INTO b VALUES (1);", Down: "DROP TABLE b;"},
+	{Version: 3, Name: "create_c", Up: "CREATE TABLE c (id integer PRIMARY KEY);", Down: "DROP TABLE c;"},
+}
+
+func Test_migrateTo(t *testing.T) {
+	tests := []struct {
+		name string
+		// the versions migrated to in order, only the last one may fail
+		versions    []int
+		migrations  []Migration
+		wantErr     bool
+		wantApplied []int
+		wantTables  []string
+	}{
+		{
+			name:        "applies every migration in order",
+			versions:    []int{3},
+			migrations:  testMigrations,
+			wantApplied: []int{1, 2, 3},
+			wantTables:  []string{"a", "b", "c"},
+		},
+		{
+			name:        "only applies migrations up to the given version",
+			versions:    []int{2},
+			migrations:  testMigrations,
+			wantApplied: []int{1, 2},
+			wantTables:  []string{"a", "b"},
+		},
+		{
+			name:        "skips applied migrations",
+			versions:    []int{1, 3, 3},
+			migrations:  testMigrations,
+			wantApplied: []int{1, 2, 3},
+			wantTables:  []string{"a", "b", "c"},
+		},
+		{
+			name:        "reverts migrations above the given version",
+			versions:    []int{3, 1},
+			migrations:  testMigrations,
+			wantApplied: []int{1},
+			wantTables:  []string{"a"},
+		},
+		{
+			name:        "reverts every migration down to version 0",
+			versions:    []int{3, 0},
+			migrations:  testMigrations,
+			wantApplied: nil,
+			wantTables:  nil,
+		},
+		{
+			name:     "rolls back a failing migration along with its record",
+			versions: []int{3},
+			migrations: []Migration{
+				testMigrations[0],
+				{Version: 2, Name: "create_b", Up: "CREATE TABLE b (id integer PRIMARY KEY); INSERT INTO missing VALUES (1);", Down: "DROP TABLE b;"},
+				testMigrations[2],
+			},
+			wantErr:     true,
+			wantApplied: []int{1},
+			wantTables:  []string{"a"},
+		},
+		{
+			name:     "refuses to revert a migration without Down statements",
+			versions: []int{2, 0},
+			migrations: []Migration{
+				testMigrations[0],
+				{Version: 2, Name: "create_b", Up: "CREATE TABLE b (id integer PRIMARY KEY);"},
+			},
+			wantErr:     true,
+			wantApplied: []int{1, 2},
+			wantTables:  []string{"a", "b"},
+		},
+		{
+			name:        "rejects unknown versions",
+			versions:    []int{4},
+			migrations:  testMigrations,
+			wantErr:     true,
+			wantApplied: nil,
+			wantTables:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			var err error
+			for _, version := range tt.versions {
+				if err = migrateTo(db, tt.migrations, version); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("migrateTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			applied, err := appliedMigrations(db)
+			if err != nil {
+				t.Fatalf("appliedMigrations() error = %v", err)
+			}
+			var gotApplied []int
+			for _, migration := range tt.migrations {
+				if _, isApplied := applied[migration.Version]; isApplied {
+					gotApplied = append(gotApplied, migration.Version)
+				}
+			}
+			if !reflect.DeepEqual(gotApplied, tt.wantApplied) {
+				t.Errorf("applied migrations = %v, want %v", gotApplied, tt.wantApplied)
+			}
+			var gotTables []string
+			for _, table := range []string{"a", "b", "c"} {
+				if db.HasTable(table) {
+					gotTables = append(gotTables, table)
+				}
+			}
+			if !reflect.DeepEqual(gotTables, tt.wantTables) {
+				t.Errorf("tables = %v, want %v", gotTables, tt.wantTables)
+			}
+		})
+	}
+}
+
+func TestMigrate_SQLite(t *testing.T) {
+	testMigrate(t, openTestDB(t))
+}
+
+// TestMigrate_Postgres runs the migration tests against the empty postgres database named by FOF_TEST_POSTGRES_URL.
+// It is skipped if the variable is not set.
+func TestMigrate_Postgres(t *testing.T) {
+	testMigrate(t, openPostgresTestDB(t))
+}
+
+// openPostgresTestDB opens the postgres database named by FOF_TEST_POSTGRES_URL, skipping the test if the variable is not set
+func openPostgresTestDB(t *testing.T) *gorm.DB {
+	connectionString := os.Getenv("FOF_TEST_POSTGRES_URL")
+	if connectionString == "" {
+		t.Skip("FOF_TEST_POSTGRES_URL is not set")
+	}
+	db, err := gorm.Open("postgres", connectionString)
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// testMigrate applies every migration to an empty database, reverts them and applies them again
+func testMigrate(t *testing.T, db *gorm.DB) {
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	saved, _ := claim.NewClaim("The sky is blue", "Daily", "http://daily.com/sky", claim.KindNews, claim.VerdictTrue, time.Now())
+	if _, err := NewClaimRepo(db).Save(saved, KeepStored); err != nil {
+		t.Errorf("ClaimRepo.Save() error = %v", err)
+	}
+	if err := MigrateTo(db, 0); err != nil {
+		t.Fatalf("MigrateTo(0) error = %v", err)
+	}
+	for _, table := range []string{claimTableName, reviewTableName, collectionRunTableName, publisherRunTableName, feedCacheTableName, publisherTableName, claimRevisionTableName, titleBandTableName} {
+		if db.HasTable(table) {
+			t.Errorf("MigrateTo(0) kept the %v table", table)
+		}
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() after MigrateTo(0) error = %v", err)
+	}
+	statuses, err := GetMigrationStatus(db)
+	if err != nil {
+		t.Fatalf("GetMigrationStatus() error = %v", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("Migration %v_%v was not applied", status.Version, status.Name)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package repo
+
+// migrations lists every change of the schema, from oldest to latest. Versions must be consecutive and start at 1.
+// Postgres databases created by gorm's AutoMigrate before migrations existed already hold some of these tables and columns,
+// which is why tables, columns and indexes are only created if they do not exist.
+// SQLite statements declare times as timestamp, the only type its driver parses as times, and uuids as varchar(36).
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_claim",
+		Up: `
+CREATE TABLE IF NOT EXISTS claim (
+	id uuid PRIMARY KEY,
+	title varchar(500) NOT NULL,
+	publisher_name varchar(50) NOT NULL,
+	url varchar(500) NOT NULL UNIQUE,
+	is_fact boolean NOT NULL,
+	reviewed_at timestamp with time zone NOT NULL
+);
+CREATE INDEX IF NOT EXISTS is_fact_and_reviewed_at_ix ON claim (is_fact, reviewed_at);`,
+		Down: `DROP TABLE claim;`,
+		SQLite: &MigrationStatements{
+			Up: `
+CREATE TABLE claim (
+	id varchar(36) PRIMARY KEY,
+	title varchar(500) NOT NULL,
+	publisher_name varchar(50) NOT NULL,
+	url varchar(500) NOT NULL UNIQUE,
+	is_fact boolean NOT NULL,
+	reviewed_at timestamp NOT NULL
+);
+CREATE INDEX is_fact_and_reviewed_at_ix ON claim (is_fact, reviewed_at);`,
+			Down: `DROP TABLE claim;`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add_claim_verdict_and_reviews",
+		Up: `
+ALTER TABLE claim
+	ADD COLUMN IF NOT EXISTS verdict varchar(20) NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS claimant varchar(200) NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS claim_date timestamp with time zone;
+UPDATE claim SET verdict = CASE WHEN is_fact THEN 'true' ELSE 'false' END WHERE verdict = '';
+CREATE TABLE IF NOT EXISTS claim_review (
+	id uuid PRIMARY KEY,
+	claim_id uuid NOT NULL,
+	publisher_name varchar(200) NOT NULL,
+	publisher_site varchar(200) NOT NULL,
+	url varchar(500) NOT NULL,
+	title varchar(500) NOT NULL,
+	textual_rating varchar(200) NOT NULL,
+	verdict varchar(20) NOT NULL,
+	language_code varchar(20) NOT NULL,
+	reviewed_at timestamp with time zone NOT NULL
+);
+CREATE INDEX IF NOT EXISTS claim_review_claim_id_ix ON claim_review (claim_id);`,
+		Down: `
+DROP TABLE claim_review;
+ALTER TABLE claim DROP COLUMN verdict, DROP COLUMN claimant, DROP COLUMN claim_date;`,
+		SQLite: &MigrationStatements{
+			Up: `
+ALTER TABLE claim ADD COLUMN verdict varchar(20) NOT NULL DEFAULT '';
+ALTER TABLE claim ADD COLUMN claimant varchar(200) NOT NULL DEFAULT '';
+ALTER TABLE claim ADD COLUMN claim_date timestamp;
+UPDATE claim SET verdict = CASE WHEN is_fact THEN 'true' ELSE 'false' END WHERE verdict = '';
+CREATE TABLE claim_review (
+	id varchar(36) PRIMARY KEY,
+	claim_id varchar(36) NOT NULL,
+	publisher_name varchar(200) NOT NULL,
+	publisher_site varchar(200) NOT NULL,
+	url varchar(500) NOT NULL,
+	title varchar(500) NOT NULL,
+	textual_rating varchar(200) NOT NULL,
+	verdict varchar(20) NOT NULL,
+	language_code varchar(20) NOT NULL,
+	reviewed_at timestamp NOT NULL
+);
+CREATE INDEX claim_review_claim_id_ix ON claim_review (claim_id);`,
+			Down: `
+DROP TABLE claim_review;
+ALTER TABLE claim DROP COLUMN verdict;
+ALTER TABLE claim DROP COLUMN claimant;
+ALTER TABLE claim DROP COLUMN claim_date;`,
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create_collection_run",
+		Up: `
+CREATE TABLE IF NOT EXISTS collection_run (
+	id uuid PRIMARY KEY,
+	started_at timestamp with time zone NOT NULL,
+	finished_at timestamp with time zone NOT NULL
+);
+CREATE INDEX IF NOT EXISTS collection_run_started_at_ix ON collection_run (started_at);
+CREATE TABLE IF NOT EXISTS collection_run_publisher (
+	id uuid PRIMARY KEY,
+	run_id uuid NOT NULL,
+	source varchar(50) NOT NULL,
+	publisher varchar(500) NOT NULL,
+	fetched integer NOT NULL,
+	parsed integer NOT NULL,
+	rejected integer NOT NULL,
+	visual_filtered integer NOT NULL,
+	new integer NOT NULL,
+	duplicate integer NOT NULL,
+	error text NOT NULL
+);
+ALTER TABLE collection_run_publisher ADD COLUMN IF NOT EXISTS not_modified boolean NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS collection_run_publisher_run_id_ix ON collection_run_publisher (run_id);`,
+		Down: `
+DROP TABLE collection_run_publisher;
+DROP TABLE collection_run;`,
+		SQLite: &MigrationStatements{
+			Up: `
+CREATE TABLE collection_run (
+	id varchar(36) PRIMARY KEY,
+	started_at timestamp NOT NULL,
+	finished_at timestamp NOT NULL
+);
+CREATE INDEX collection_run_started_at_ix ON collection_run (started_at);
+CREATE TABLE collection_run_publisher (
+	id varchar(36) PRIMARY KEY,
+	run_id varchar(36) NOT NULL,
+	source varchar(50) NOT NULL,
+	publisher varchar(500) NOT NULL,
+	fetched integer NOT NULL,
+	parsed integer NOT NULL,
+	rejected integer NOT NULL,
+	visual_filtered integer NOT NULL,
+	not_modified boolean NOT NULL DEFAULT false,
+	new integer NOT NULL,
+	duplicate integer NOT NULL,
+	error text NOT NULL
+);
+CREATE INDEX collection_run_publisher_run_id_ix ON collection_run_publisher (run_id);`,
+			Down: `
+DROP TABLE collection_run_publisher;
+DROP TABLE collection_run;`,
+		},
+	},
+	{
+		Version: 4,
+		Name:    "create_rss_feed_cache",
+		Up: `
+CREATE TABLE IF NOT EXISTS rss_feed_cache (
+	url varchar(2000) PRIMARY KEY,
+	etag varchar(500) NOT NULL,
+	last_modified varchar(100) NOT NULL,
+	updated_at timestamp with time zone NOT NULL
+);`,
+		Down: `DROP TABLE rss_feed_cache;`,
+		SQLite: &MigrationStatements{
+			Up: `
+CREATE TABLE rss_feed_cache (
+	url varchar(2000) PRIMARY KEY,
+	etag varchar(500) NOT NULL,
+	last_modified varchar(100) NOT NULL,
+	updated_at timestamp NOT NULL
+);`,
+			Down: `DROP TABLE rss_feed_cache;`,
+		},
+	},
+	{
+		Version: 5,
+		Name:    "create_publisher",
+		Up: `
+CREATE TABLE IF NOT EXISTS publisher (
+	id uuid PRIMARY KEY,
+	name varchar(50) NOT NULL UNIQUE,
+	homepage varchar(500) NOT NULL,
+	logo_url varchar(500) NOT NULL,
+	language_code varchar(20) NOT NULL,
+	trust_label varchar(50) NOT NULL
+);
+ALTER TABLE claim ADD COLUMN IF NOT EXISTS publisher_id uuid;
+CREATE INDEX IF NOT EXISTS claim_publisher_id_ix ON claim (publisher_id);`,
+		Down: `
+ALTER TABLE claim DROP COLUMN publisher_id;
+DROP TABLE publisher;`,
+		SQLite: &MigrationStatements{
+			Up: `
+CREATE TABLE publisher (
+	id varchar(36) PRIMARY KEY,
+	name varchar(50) NOT NULL UNIQUE,
+	homepage varchar(500) NOT NULL,
+	logo_url varchar(500) NOT NULL,
+	language_code varchar(20) NOT NULL,
+	trust_label varchar(50) NOT NULL
+);
+ALTER TABLE claim ADD COLUMN publisher_id varchar(36);
+CREATE INDEX claim_publisher_id_ix ON claim (publisher_id);`,
+			Down: `
+DROP INDEX claim_publisher_id_ix;
+ALTER TABLE claim DROP COLUMN publisher_id;
+DROP TABLE publisher;`,
+		},
+	},
+	{
+		Version: 6,
+		Name:    "add_claim_kind_and_appearance_url",
+		Up: `
+ALTER TABLE claim
+	ADD COLUMN IF NOT EXISTS kind varchar(20) NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS appearance_url varchar(2000) NOT NULL DEFAULT '';
+UPDATE claim SET kind = 'fact-check'
+	WHERE kind = '' AND EXISTS (SELECT 1 FROM claim_review WHERE claim_review.claim_id = claim.id);`,
+		Down: `ALTER TABLE claim DROP COLUMN kind, DROP COLUMN appearance_url;`,
+		SQLite: &MigrationStatements{
+			Up: `
+ALTER TABLE claim ADD COLUMN kind varchar(20) NOT NULL DEFAULT '';
+ALTER TABLE claim ADD COLUMN appearance_url varchar(2000) NOT NULL DEFAULT '';
+UPDATE claim SET kind = 'fact-check'
+	WHERE kind = '' AND EXISTS (SELECT 1 FROM claim_review WHERE claim_review.claim_id = claim.id);`,
+			Down: `
+ALTER TABLE claim DROP COLUMN kind;
+ALTER TABLE claim DROP COLUMN appearance_url;`,
+		},
+	},
+	{
+		// publisher names were truncated to 50 characters, which cut off the names of many RSS feeds
+		Version: 7,
+		Name:    "widen_publisher_name",
+		Up: `
+ALTER TABLE claim ALTER COLUMN publisher_name TYPE varchar(200);
+ALTER TABLE publisher ALTER COLUMN name TYPE varchar(200);`,
+		Down: `
+UPDATE claim SET publisher_name = left(publisher_name, 50) WHERE length(publisher_name) > 50;
+ALTER TABLE claim ALTER COLUMN publisher_name TYPE varchar(50);
+UPDATE claim SET publisher_id = NULL WHERE publisher_id IN (SELECT id FROM publisher WHERE length(name) > 50);
+DELETE FROM publisher WHERE length(name) > 50;
+ALTER TABLE publisher ALTER COLUMN name TYPE varchar(50);`,
+		SQLite: &MigrationStatements{
+			// SQLite does not enforce the length of varchar columns
+		},
+	},
+	{
+		Version: 8,
+		Name:    "create_claim_revision",
+		Up: `
+CREATE TABLE claim_revision (
+	id uuid PRIMARY KEY,
+	claim_id uuid NOT NULL,
+	title varchar(500) NOT NULL,
+	verdict varchar(20) NOT NULL,
+	is_fact boolean NOT NULL,
+	reviewed_at timestamp with time zone NOT NULL,
+	revised_at timestamp with time zone NOT NULL
+);
+CREATE INDEX claim_revision_claim_id_ix ON claim_revision (claim_id, revised_at);`,
+		Down: `DROP TABLE claim_revision;`,
+		SQLite: &MigrationStatements{
+			Up: `
+CREATE TABLE claim_revision (
+	id varchar(36) PRIMARY KEY,
+	claim_id varchar(36) NOT NULL,
+	title varchar(500) NOT NULL,
+	verdict varchar(20) NOT NULL,
+	is_fact boolean NOT NULL,
+	reviewed_at timestamp NOT NULL,
+	revised_at timestamp NOT NULL
+);
+CREATE INDEX claim_revision_claim_id_ix ON claim_revision (claim_id, revised_at);`,
+			Down: `DROP TABLE claim_revision;`,
+		},
+	},
+	{
+		// claims whose titles are near duplicates share a cluster, claim_title_band indexes titles to find near duplicates.
+		// Existing claims each get their own cluster until the cluster command groups them
+		Version: 9,
+		Name:    "add_claim_cluster",
+		Up: `
+ALTER TABLE claim ADD COLUMN cluster_id uuid;
+UPDATE claim SET cluster_id = id;
+CREATE INDEX claim_cluster_id_ix ON claim (cluster_id);
+CREATE TABLE claim_title_band (
+	hash bigint NOT NULL,
+	claim_id uuid NOT NULL,
+	PRIMARY KEY (hash, claim_id)
+);
+CREATE INDEX claim_title_band_claim_id_ix ON claim_title_band (claim_id);`,
+		Down: `
+DROP TABLE claim_title_band;
+DROP INDEX claim_cluster_id_ix;
+ALTER TABLE claim DROP COLUMN cluster_id;`,
+		SQLite: &MigrationStatements{
+			Up: `
+ALTER TABLE claim ADD COLUMN cluster_id varchar(36);
+UPDATE claim SET cluster_id = id;
+CREATE INDEX claim_cluster_id_ix ON claim (cluster_id);
+CREATE TABLE claim_title_band (
+	hash bigint NOT NULL,
+	claim_id varchar(36) NOT NULL,
+	PRIMARY KEY (hash, claim_id)
+);
+CREATE INDEX claim_title_band_claim_id_ix ON claim_title_band (claim_id);`,
+			Down: `
+DROP TABLE claim_title_band;
+DROP INDEX claim_cluster_id_ix;
+ALTER TABLE claim DROP COLUMN cluster_id;`,
+		},
+	},
+	{
+		// the URL a claim was collected with before it was canonicalized, empty for claims stored with their original URL
+		Version: 10,
+		Name:    "add_claim_original_url",
+		Up:      `ALTER TABLE claim ADD COLUMN original_url varchar(2000) NOT NULL DEFAULT '';`,
+		Down:    `ALTER TABLE claim DROP COLUMN original_url;`,
+	},
+}
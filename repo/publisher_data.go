@@ -0,0 +1,97 @@
+package repo
+
+import (
+	"fake-or-fact/claim"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+type PublisherData struct {
+	ID           uuid.UUID `gorm:"column:id;primary_key"`
+	Name         string    `gorm:"column:name;type:varchar(200);unique;not null"`
+	Homepage     string    `gorm:"column:homepage;type:varchar(500);not null"`
+	LogoURL      string    `gorm:"column:logo_url;type:varchar(500);not null"`
+	LanguageCode string    `gorm:"column:language_code;type:varchar(20);not null"`
+	TrustLabel   string    `gorm:"column:trust_label;type:varchar(50);not null"`
+}
+
+const publisherTableName string = "publisher"
+
+func (PublisherData) TableName() string {
+	return publisherTableName
+}
+
+type PublisherRepo interface {
+	Save(publisher claim.Publisher) error
+	GetAll() ([]claim.Publisher, error)
+}
+
+type pgPublisherRepo struct {
+	db *gorm.DB
+}
+
+func NewPublisherRepo(db *gorm.DB) PublisherRepo {
+	return &pgPublisherRepo{db}
+}
+
+// Save persists a publisher, replacing the stored publisher with the same name if any.
+// Stored claims attributed to the publisher's name are linked to it.
+func (repo *pgPublisherRepo) Save(publisher claim.Publisher) error {
+	publisherData := asPublisherData(publisher)
+	existingPublisher := new(PublisherData)
+	queryErr := repo.db.Where("name = ?", publisher.Name).First(existingPublisher).Error
+	var saveErr error
+	if queryErr == nil {
+		publisherData.ID = existingPublisher.ID
+		saveErr = repo.db.Save(&publisherData).Error
+	} else if gorm.IsRecordNotFoundError(queryErr) {
+		saveErr = repo.db.Create(&publisherData).Error
+	} else {
+		return queryErr
+	}
+	if saveErr != nil {
+		return saveErr
+	}
+	return repo.db.Model(&ClaimData{}).
+		Where("publisher_name = ? AND publisher_id IS NULL", publisherData.Name).
+		UpdateColumn("publisher_id", publisherData.ID).
+		Error
+}
+
+// GetAll returns every publisher ordered by name
+func (repo *pgPublisherRepo) GetAll() ([]claim.Publisher, error) {
+	foundPublisherData := make([]PublisherData, 0)
+	err := repo.db.Order("name ASC").Find(&foundPublisherData).Error
+	if err != nil {
+		return nil, err
+	}
+	publishers := make([]claim.Publisher, 0, len(foundPublisherData))
+	for _, publisherData := range foundPublisherData {
+		publishers = append(publishers, asPublisher(publisherData))
+	}
+	return publishers, nil
+}
+
+// returns a new PublisherData based on a Publisher.
+func asPublisherData(publisher claim.Publisher) PublisherData {
+	return PublisherData{
+		ID:           uuid.NewV4(),
+		Name:         publisher.Name,
+		Homepage:     publisher.Homepage,
+		LogoURL:      publisher.LogoURL,
+		LanguageCode: publisher.LanguageCode,
+		TrustLabel:   publisher.TrustLabel,
+	}
+}
+
+// returns a new Publisher based on a PublisherData.
+func asPublisher(publisherData PublisherData) claim.Publisher {
+	return claim.Publisher{
+		Name:         publisherData.Name,
+		Homepage:     publisherData.Homepage,
+		LogoURL:      publisherData.LogoURL,
+		LanguageCode: publisherData.LanguageCode,
+		TrustLabel:   publisherData.TrustLabel,
+	}
+}
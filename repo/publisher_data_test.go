@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"fake-or-fact/claim"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPublisherRepo_SaveAndGetAll(t *testing.T) {
+	db := openTestDB(t)
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	c, _ := claim.NewClaim("Claim", "Daily", "https://daily.com/claim", claim.KindNews, claim.VerdictTrue, time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC))
+	if _, err := NewClaimRepo(db).Save(c, KeepStored); err != nil {
+		t.Fatalf("ClaimRepo.Save() error = %v", err)
+	}
+
+	daily := claim.Publisher{Name: "Daily", Homepage: "https://daily.com", LogoURL: "https://daily.com/logo.png", LanguageCode: "en", TrustLabel: "reliable"}
+	checker := claim.Publisher{Name: "Checker", Homepage: "https://checker.com", LanguageCode: "fr"}
+	updatedDaily := daily
+	updatedDaily.TrustLabel = "mixed"
+	publishers := NewPublisherRepo(db)
+	for _, publisher := range []claim.Publisher{daily, checker, updatedDaily} {
+		if err := publishers.Save(publisher); err != nil {
+			t.Fatalf("PublisherRepo.Save() error = %v", err)
+		}
+	}
+
+	got, err := publishers.GetAll()
+	if err != nil {
+		t.Fatalf("PublisherRepo.GetAll() error = %v", err)
+	}
+	if want := []claim.Publisher{checker, updatedDaily}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PublisherRepo.GetAll() = %+v, want %+v", got, want)
+	}
+	var dailyData PublisherData
+	var claimData ClaimData
+	if err := db.Where("name = ?", "Daily").First(&dailyData).Error; err != nil {
+		t.Fatalf("Failed to load publisher: %v", err)
+	}
+	if err := db.Where("url = ?", c.URL).First(&claimData).Error; err != nil {
+		t.Fatalf("Failed to load claim: %v", err)
+	}
+	if claimData.PublisherID == nil || *claimData.PublisherID != dailyData.ID {
+		t.Errorf("claim publisher ID = %v, want %v", claimData.PublisherID, dailyData.ID)
+	}
+}
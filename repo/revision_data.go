@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"fake-or-fact/claim"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// ClaimRevision holds the values of a stored claim which were replaced when its publisher revised it
+type ClaimRevision struct {
+	Title      string
+	Verdict    claim.Verdict
+	IsFact     bool
+	ReviewedAt time.Time
+	// the time at which the values were replaced
+	RevisedAt time.Time
+}
+
+// ClaimHistory is a stored claim along with its previous values, from latest to oldest
+type ClaimHistory struct {
+	Claim     claim.Claim
+	Revisions []ClaimRevision
+}
+
+type ClaimRevisionData struct {
+	ID         uuid.UUID `gorm:"column:id;primary_key"`
+	ClaimID    uuid.UUID `gorm:"column:claim_id;not null;index:claim_revision_claim_id_ix"`
+	Title      string    `gorm:"column:title;type:varchar(500);not null"`
+	Verdict    string    `gorm:"column:verdict;type:varchar(20);not null"`
+	IsFact     bool      `gorm:"column:is_fact;not null"`
+	ReviewedAt time.Time `gorm:"column:reviewed_at;not null"`
+	RevisedAt  time.Time `gorm:"column:revised_at;not null;index:claim_revision_claim_id_ix"`
+}
+
+const claimRevisionTableName string = "claim_revision"
+
+func (ClaimRevisionData) TableName() string {
+	return claimRevisionTableName
+}
+
+// returns the revision replacing the values of the stored claim at the given time.
+func asClaimRevisionData(stored ClaimData, revisedAt time.Time) ClaimRevisionData {
+	return ClaimRevisionData{
+		ID:         uuid.NewV4(),
+		ClaimID:    stored.ID,
+		Title:      stored.Title,
+		Verdict:    stored.Verdict,
+		IsFact:     stored.IsFact,
+		ReviewedAt: stored.ReviewedAt.UTC(),
+		RevisedAt:  revisedAt.UTC(),
+	}
+}
+
+// returns a new ClaimRevision based on a ClaimRevisionData.
+func asClaimRevision(revisionData ClaimRevisionData) ClaimRevision {
+	return ClaimRevision{
+		Title:      revisionData.Title,
+		Verdict:    claim.Verdict(revisionData.Verdict),
+		IsFact:     revisionData.IsFact,
+		ReviewedAt: revisionData.ReviewedAt,
+		RevisedAt:  revisionData.RevisedAt,
+	}
+}
+
+// isRevised returns true if the collected claim has another title, verdict or review date than the stored claim.
+// Review dates are compared to the microsecond, the precision of postgres timestamps.
+func isRevised(stored ClaimData, collected ClaimData) bool {
+	return stored.Title != collected.Title ||
+		stored.Verdict != collected.Verdict ||
+		!stored.ReviewedAt.Truncate(time.Microsecond).Equal(collected.ReviewedAt.Truncate(time.Microsecond))
+}
@@ -0,0 +1,163 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// CollectionRun is a run of the claim collector, along with the outcome of every publisher it queried
+type CollectionRun struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Publishers []PublisherRun
+}
+
+// PublisherRun is the outcome of the collection of a publisher's claims during a run
+type PublisherRun struct {
+	// the group of sources the publisher belongs to, e.g. "google"
+	Source    string
+	Publisher string
+	// the number of items returned by the publisher
+	Fetched int
+	// the number of items which could be converted to claims
+	Parsed int
+	// the number of items which could not be converted to claims
+	Rejected int
+	// the number of claims which were not persisted since they reference visual media
+	VisualFiltered int
+	// true if the publisher reported that nothing changed since the previous run
+	NotModified bool
+	// the number of claims which were persisted
+	New int
+	// the number of claims which were already persisted
+	Duplicate int
+	// the error encountered while fetching the publisher's claims, empty on success
+	Error string
+}
+
+type CollectionRunData struct {
+	ID         uuid.UUID          `gorm:"column:id;primary_key"`
+	StartedAt  time.Time          `gorm:"column:started_at;not null;index:collection_run_started_at_ix"`
+	FinishedAt time.Time          `gorm:"column:finished_at;not null"`
+	Publishers []PublisherRunData `gorm:"foreignkey:RunID"`
+}
+
+const collectionRunTableName string = "collection_run"
+
+func (CollectionRunData) TableName() string {
+	return collectionRunTableName
+}
+
+type PublisherRunData struct {
+	ID             uuid.UUID `gorm:"column:id;primary_key"`
+	RunID          uuid.UUID `gorm:"column:run_id;not null;index:collection_run_publisher_run_id_ix"`
+	Source         string    `gorm:"column:source;type:varchar(50);not null"`
+	Publisher      string    `gorm:"column:publisher;type:varchar(500);not null"`
+	Fetched        int       `gorm:"column:fetched;not null"`
+	Parsed         int       `gorm:"column:parsed;not null"`
+	Rejected       int       `gorm:"column:rejected;not null"`
+	VisualFiltered int       `gorm:"column:visual_filtered;not null"`
+	NotModified    bool      `gorm:"column:not_modified;not null;default:false"`
+	New            int       `gorm:"column:new;not null"`
+	Duplicate      int       `gorm:"column:duplicate;not null"`
+	Error          string    `gorm:"column:error;type:text;not null"`
+}
+
+const publisherRunTableName string = "collection_run_publisher"
+
+func (PublisherRunData) TableName() string {
+	return publisherRunTableName
+}
+
+type RunRepo interface {
+	Save(run CollectionRun) error
+	GetLatest(limit int) ([]CollectionRun, error)
+}
+
+type pgRunRepo struct {
+	db *gorm.DB
+}
+
+func NewRunRepo(db *gorm.DB) RunRepo {
+	return &pgRunRepo{db}
+}
+
+// Save persists a collection run along with the outcome of its publishers
+func (repo *pgRunRepo) Save(run CollectionRun) error {
+	runData := asCollectionRunData(run)
+	return repo.db.Create(&runData).Error
+}
+
+// GetLatest returns the latest collection runs from latest to oldest, limited to the given number of runs.
+// The publishers of a run are ordered by source and name.
+func (repo *pgRunRepo) GetLatest(limit int) ([]CollectionRun, error) {
+	foundRunData := make([]CollectionRunData, 0, limit)
+	err := repo.db.Preload("Publishers", orderPublisherRuns).Order("started_at DESC").Limit(limit).Find(&foundRunData).Error
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]CollectionRun, 0, len(foundRunData))
+	for _, runData := range foundRunData {
+		runs = append(runs, asCollectionRun(runData))
+	}
+	return runs, nil
+}
+
+// orders preloaded publisher runs by source and name
+func orderPublisherRuns(db *gorm.DB) *gorm.DB {
+	return db.Order("source ASC, publisher ASC")
+}
+
+// returns a new CollectionRunData based on a CollectionRun.
+func asCollectionRunData(run CollectionRun) CollectionRunData {
+	runID := uuid.NewV4()
+	publishers := make([]PublisherRunData, 0, len(run.Publishers))
+	for _, publisher := range run.Publishers {
+		publishers = append(publishers, PublisherRunData{
+			ID:             uuid.NewV4(),
+			RunID:          runID,
+			Source:         publisher.Source,
+			Publisher:      publisher.Publisher,
+			Fetched:        publisher.Fetched,
+			Parsed:         publisher.Parsed,
+			Rejected:       publisher.Rejected,
+			VisualFiltered: publisher.VisualFiltered,
+			NotModified:    publisher.NotModified,
+			New:            publisher.New,
+			Duplicate:      publisher.Duplicate,
+			Error:          publisher.Error,
+		})
+	}
+	return CollectionRunData{
+		ID:         runID,
+		StartedAt:  run.StartedAt.UTC(),
+		FinishedAt: run.FinishedAt.UTC(),
+		Publishers: publishers,
+	}
+}
+
+// returns a new CollectionRun based on a CollectionRunData.
+func asCollectionRun(runData CollectionRunData) CollectionRun {
+	publishers := make([]PublisherRun, 0, len(runData.Publishers))
+	for _, publisherData := range runData.Publishers {
+		publishers = append(publishers, PublisherRun{
+			Source:         publisherData.Source,
+			Publisher:      publisherData.Publisher,
+			Fetched:        publisherData.Fetched,
+			Parsed:         publisherData.Parsed,
+			Rejected:       publisherData.Rejected,
+			VisualFiltered: publisherData.VisualFiltered,
+			NotModified:    publisherData.NotModified,
+			New:            publisherData.New,
+			Duplicate:      publisherData.Duplicate,
+			Error:          publisherData.Error,
+		})
+	}
+	return CollectionRun{
+		StartedAt:  runData.StartedAt,
+		FinishedAt: runData.FinishedAt,
+		Publishers: publishers,
+	}
+}
@@ -0,0 +1,41 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunRepo_SaveAndGetLatest(t *testing.T) {
+	db := openTestDB(t)
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	startedAt := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	older := CollectionRun{StartedAt: startedAt, FinishedAt: startedAt.Add(time.Minute)}
+	latest := CollectionRun{
+		StartedAt:  startedAt.Add(time.Hour),
+		FinishedAt: startedAt.Add(time.Hour + time.Minute),
+		Publishers: []PublisherRun{
+			{Source: "real-rss", Publisher: "http://daily.com/feed", Fetched: 10, NotModified: true},
+			{Source: "google", Publisher: "checker.com", Fetched: 5, Parsed: 4, Rejected: 1, VisualFiltered: 1, New: 2, Duplicate: 1},
+			{Source: "google", Publisher: "broken.com", Error: "503 Service Unavailable"},
+		},
+	}
+	runs := NewRunRepo(db)
+	for _, run := range []CollectionRun{older, latest} {
+		if err := runs.Save(run); err != nil {
+			t.Fatalf("RunRepo.Save() error = %v", err)
+		}
+	}
+
+	got, err := runs.GetLatest(1)
+	if err != nil {
+		t.Fatalf("RunRepo.GetLatest() error = %v", err)
+	}
+	want := latest
+	want.Publishers = []PublisherRun{latest.Publishers[2], latest.Publishers[1], latest.Publishers[0]}
+	if len(got) != 1 || !got[0].StartedAt.Equal(want.StartedAt) || !got[0].FinishedAt.Equal(want.FinishedAt) || !reflect.DeepEqual(got[0].Publishers, want.Publishers) {
+		t.Errorf("RunRepo.GetLatest() = %+v, want %+v", got, want)
+	}
+}